@@ -2,6 +2,7 @@ package main
 
 import (
 	"encoding/json"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
@@ -9,6 +10,7 @@ import (
 	"time"
 
 	"quel-canvas-server/modules/common/config"
+	redisClient "quel-canvas-server/modules/common/redis"
 	klingmigration "quel-canvas-server/modules/kling-migration"
 	landingdemo "quel-canvas-server/modules/landing-demo"
 	"quel-canvas-server/modules/modify"
@@ -27,12 +29,9 @@ import (
 
 // WebSocket upgrader
 var upgrader = websocket.Upgrader{
-	CheckOrigin: func(r *http.Request) bool {
-		// 개발용 - 모든 origin 허용
-		// 프로덕션에서는 특정 도메인만 허용하도록 수정
-		return true
-	},
-	EnableCompression: true, // WebSocket 압축 활성화
+	CheckOrigin:       checkOrigin,                 // WS_ALLOWED_ORIGINS 기반 (미설정 시 거부, 인증 비활성화 시 모두 허용)
+	Subprotocols:      []string{bearerSubprotocol}, // Sec-WebSocket-Protocol 토큰 전달 시 "bearer" 선택
+	EnableCompression: true,                        // WebSocket 압축 활성화
 }
 
 // 연결된 클라이언트 정보
@@ -43,22 +42,62 @@ type Client struct {
 	userId      string
 	userName    string
 	userInfo    map[string]interface{}
-	send        chan []byte
+	outbox      *clientOutbox // 전송 대기열 (writePump가 비움)
+
+	// Room 권한 (viewer/editor/host) - 호스트가 바꿀 수 있으므로 mutex로 보호
+	role      string
+	roleMutex sync.RWMutex
 }
 
 // 세션 관리 (Room으로 사용)
 type Session struct {
 	id           string
-	clients      map[string]*Client
+	clients      map[string]*Client // hub 고루틴만 변경
 	mutex        sync.RWMutex
 	createdAt    time.Time
 	lastActivity time.Time
 
+	// hub 이벤트 루프 (room_hub.go)
+	register    chan hubRegister
+	unregister  chan hubUnregister
+	outbound    chan hubOutbound
+	stopRequest chan hubStop
+	stopped     chan struct{} // hub 종료 시 close
+
 	// Visual Editor 상태 저장 (협업용)
 	nodes        []interface{}          // React Flow nodes
 	edges        []interface{}          // React Flow edges
 	lastSyncBy   string                 // 마지막으로 상태를 동기화한 사용자 ID
 	lastSyncAt   time.Time              // 마지막 동기화 시간
+
+	// 델타 동기화 (patch-nodes)
+	revision         int64            // Room 그래프 revision (변경마다 1씩 증가)
+	fullSyncRevision int64            // 마지막 전체 동기화(sync-nodes) revision
+	elementRevs      map[string]int64 // "node:{id}"/"edge:{id}" → 마지막 변경 revision
+	resyncTarget     int64            // 다른 인스턴스 패치를 놓쳐 스냅샷으로 따라잡아야 할 revision (0이면 정상)
+
+	roleOverrides map[string]string // 호스트가 지정한 사용자별 권한 (재접속 시 유지)
+
+	history *roomHistory // 사용자별 undo/redo 기록
+
+	locks map[string]itemLock // 아이템 ID → 드래그/편집 잠금
+
+	presence presenceBuffer // 다음 tick에 묶어 보낼 커서/선택
+
+	recordingId string // 진행 중인 녹화 (비어있으면 녹화 안 함)
+
+	// 호스트 화면 따라가기
+	viewport  *hostViewport   // 호스트가 마지막으로 보낸 화면 위치
+	followers map[string]bool // viewport-update를 받을 사용자 ID
+
+	// 재접속 재전송
+	seq         int64         // 마지막 Room 메시지 순번
+	replay      []replayEntry // 최근 메시지 (순번 오름차순, 최대 replayBufferSize개)
+	resumeFloor int64         // 이보다 작은 순번으로 resume하면 전체 상태 재전송 (순번 없이 보낸 메시지 이후 첫 순번)
+
+	// 스냅샷 영속화
+	loadOnce      sync.Once   // 저장된 스냅샷 지연 로드 (최초 1회)
+	snapshotTimer *time.Timer // 예약된 스냅샷 저장 (debounce)
 }
 
 // 세션 매니저
@@ -115,24 +154,42 @@ type Message struct {
 	WorkspaceId string                 `json:"workspace_id,omitempty"` // 워크스페이스 ID
 	UserName    string                 `json:"user_name,omitempty"`    // 사용자 이름
 	Data        map[string]interface{} `json:"data,omitempty"`         // 범용 데이터 (nodes, edges 등)
+
+	// Visual Editor 델타 동기화 필드들
+	Revision     int64     `json:"revision,omitempty"`     // 적용 후 Room revision
+	BaseRevision int64     `json:"baseRevision,omitempty"` // 패치를 만든 기준 revision
+	Ops          []graphOp `json:"ops,omitempty"`          // 노드/엣지 연산 목록
+	Reason       string    `json:"reason,omitempty"`       // 거부 사유
+	Conflicts    []string  `json:"conflicts,omitempty"`    // 충돌한 요소 키
+
+	// Room 권한 관련 필드들
+	Role         string `json:"role,omitempty"`         // viewer | editor | host
+	TargetUserId string `json:"targetUserId,omitempty"` // 권한 변경 대상 사용자
+
+	Seq int64 `json:"seq,omitempty"` // Room 메시지 순번 (resume 요청 시 마지막으로 받은 순번)
+
+	Updates []Message `json:"updates,omitempty"` // presence-batch: 사용자별 최신 커서/선택 메시지
+}
+
+// 세션 조회 (없으면 nil)
+func (sm *SessionManager) getSession(sessionId string) *Session {
+	sm.mutex.RLock()
+	defer sm.mutex.RUnlock()
+	return sm.sessions[sessionId]
 }
 
 // 세션 가져오기 또는 생성
 func (sm *SessionManager) getOrCreateSession(sessionId string) *Session {
 	sm.mutex.Lock()
-	defer sm.mutex.Unlock()
 
 	session, exists := sm.sessions[sessionId]
 	if !exists {
-		now := time.Now()
-		session = &Session{
-			id:           sessionId,
-			clients:      make(map[string]*Client),
-			createdAt:    now,
-			lastActivity: now,
-		}
+		session = newSession(sessionId)
 		sm.sessions[sessionId] = session
 
+		// 다른 인스턴스의 같은 Room 메시지 수신
+		roomBus.subscribe(sessionId)
+
 		// 메트릭 업데이트
 		sm.metrics.mutex.Lock()
 		sm.metrics.TotalSessions++
@@ -144,25 +201,34 @@ func (sm *SessionManager) getOrCreateSession(sessionId string) *Session {
 	}
 
 	// 활동 시간 업데이트
+	session.mutex.Lock()
 	session.lastActivity = time.Now()
+	session.mutex.Unlock()
+	sm.mutex.Unlock()
+
+	// 저장된 Room 상태 로드 (매니저 락 밖에서, 최초 1회)
+	session.ensureLoaded()
 	return session
 }
 
-// 클라이언트를 세션에 추가
-func (s *Session) addClient(client *Client) {
-	s.mutex.Lock()
-	s.clients[client.userId] = client
-	s.lastActivity = time.Now()
-	clientCount := len(s.clients)
-	s.mutex.Unlock()
+// 클라이언트를 세션에 추가 (세션이 이미 정리됐으면 false)
+func (s *Session) addClient(client *Client) bool {
+	clientCount, ok := s.join(client)
+	if !ok {
+		return false
+	}
 
 	// 메트릭 업데이트
 	sessionManager.metrics.mutex.Lock()
 	sessionManager.metrics.TotalConnections++
+	totalConnections := sessionManager.metrics.TotalConnections
 	sessionManager.metrics.mutex.Unlock()
 
 	log.Printf("Client %s joined session %s (Clients: %d, Total Connections: %d)",
-		client.userId, s.id, clientCount, sessionManager.metrics.TotalConnections)
+		client.userId, s.id, clientCount, totalConnections)
+
+	// 다른 인스턴스에서도 접속자 목록을 볼 수 있도록 등록
+	roomBus.join(s.id, client)
 
 	// user_joined 메시지를 모든 클라이언트에게 브로드캐스트 (mutex 해제 후)
 	joinMessage := Message{
@@ -173,43 +239,83 @@ func (s *Session) addClient(client *Client) {
 		SessionId:   s.id,
 		OrgId:       client.orgId,
 		WorkspaceId: client.workspaceId,
+		Role:        client.getRole(),
 	}
 	s.broadcastToAll(joinMessage)
 	log.Printf("📢 Broadcasted user_joined for %s (%s) to all clients in room %s", client.userName, client.userId, s.id)
+	return true
 }
 
-// 클라이언트를 세션에서 제거
-func (s *Session) removeClient(userId string) {
-	s.mutex.Lock()
-	defer s.mutex.Unlock()
-	if client, exists := s.clients[userId]; exists {
-		close(client.send)
-		delete(s.clients, userId)
-		s.lastActivity = time.Now()
-
-		log.Printf("👋 Client %s left session %s (Remaining: %d)", userId, s.id, len(s.clients))
-
-		// 다른 클라이언트들에게 사용자 퇴장 알림
-		userLeftMsg := Message{
-			Type:        "user_left",
-			UserId:      userId,
-			UserName:    client.userName,
-			OrgId:       client.orgId,
-			WorkspaceId: client.workspaceId,
-		}
-		s.broadcastToOthers(userId, userLeftMsg)
+// 클라이언트를 세션에서 제거 (여러 번 호출돼도 한 번만 처리)
+func (s *Session) removeClient(client *Client) {
+	result := s.leave(client)
+	if !result.removed {
+		return
+	}
+	userId := client.userId
+	remaining := result.remaining
+	releasedLocks := result.releasedLocks
 
-		// 세션이 비어있으면 정리 스케줄링
-		if len(s.clients) == 0 {
-			log.Printf("🗑️  Session %s is now empty, will be cleaned up", s.id)
-		}
+	log.Printf("👋 Client %s left session %s (Remaining: %d)", userId, s.id, remaining)
+
+	roomBus.leave(s.id, userId)
+
+	// 나간 사용자가 잡고 있던 잠금 해제 알림
+	for _, itemId := range releasedLocks {
+		s.releaseSharedLock(itemId, userId)
+		s.broadcastUnlocked(itemId, userId, client.userName, "left")
+	}
+
+	// 다른 클라이언트들에게 사용자 퇴장 알림 (mutex 해제 후)
+	userLeftMsg := Message{
+		Type:        "user_left",
+		UserId:      userId,
+		UserName:    client.userName,
+		OrgId:       client.orgId,
+		WorkspaceId: client.workspaceId,
+	}
+	s.broadcastToOthers(userId, userLeftMsg)
+
+	// 세션이 비어있으면 정리 스케줄링
+	if remaining == 0 {
+		log.Printf("🗑️  Session %s is now empty, will be cleaned up", s.id)
 	}
 }
 
-// 다른 클라이언트들에게 메시지 브로드캐스트
-func (s *Session) broadcastToOthers(senderUserId string, message Message) {
+// 이 인스턴스에 연결된 클라이언트 조회 (없으면 nil)
+func (s *Session) getClient(userId string) *Client {
 	s.mutex.RLock()
 	defer s.mutex.RUnlock()
+	return s.clients[userId]
+}
+
+// 다른 클라이언트들에게 메시지 브로드캐스트
+func (s *Session) broadcastToOthers(senderUserId string, message Message) {
+	s.broadcast(senderUserId, message)
+}
+
+// 모든 클라이언트에게 메시지 브로드캐스트 (자신 포함)
+func (s *Session) broadcastToAll(message Message) {
+	if message.Type == "history_visibility_update" {
+		log.Printf("📤 Broadcasting history_visibility_update (showCreationHistory: %v, productions: %d)",
+			message.ShowCreationHistory, len(message.HostProductions))
+	} else {
+		log.Printf("Broadcasting message type '%s' in room %s", message.Type, s.id)
+	}
+
+	s.broadcast("", message)
+}
+
+// 순번을 붙여 로컬 전달 + 다른 인스턴스로 전파 (excludeUserId는 제외)
+func (s *Session) broadcast(excludeUserId string, message Message) {
+	// 커서/선택 같은 일회성 메시지는 재전송 대상이 아니므로 순번 없이 전달
+	if !ephemeralMessageTypes[message.Type] {
+		seq, ok := s.nextSeq()
+		if !ok {
+			s.markSeqGap()
+		}
+		message.Seq = seq
+	}
 
 	messageBytes, err := json.Marshal(message)
 	if err != nil {
@@ -217,43 +323,106 @@ func (s *Session) broadcastToOthers(senderUserId string, message Message) {
 		return
 	}
 
-	for userId, client := range s.clients {
-		if userId != senderUserId {
-			select {
-			case client.send <- messageBytes:
-			default:
-				close(client.send)
-				delete(s.clients, userId)
-			}
-		}
+	if message.Seq > 0 {
+		s.recordReplay(message.Seq, excludeUserId, messageBytes)
+		s.recordMessage(message.Type, messageBytes)
 	}
+	s.deliverLocal(excludeUserId, coalesceKey(&message), messageBytes)
+	roomBus.publish(s.id, excludeUserId, messageBytes)
 }
 
-// 모든 클라이언트에게 메시지 브로드캐스트 (자신 포함)
-func (s *Session) broadcastToAll(message Message) {
-	s.mutex.RLock()
-	defer s.mutex.RUnlock()
+// 다른 인스턴스에서 온 메시지 처리
+func (s *Session) handleRemote(envelope roomEnvelope) {
+	var message Message
+	if err := json.Unmarshal(envelope.Payload, &message); err != nil {
+		log.Printf("⚠️ [RoomBus] Invalid payload in room %s: %v", s.id, err)
+		return
+	}
 
-	messageBytes, err := json.Marshal(message)
-	if err != nil {
-		log.Printf("Error marshaling message: %v", err)
+	// Room 상태를 바꾸는 메시지는 로컬 세션에도 반영 (request-state 일관성)
+	switch message.Type {
+	case "nodes-updated":
+		s.applyNodesSync(message.UserId, message.Data, message.Revision)
+	case "nodes-patched":
+		// 순서가 맞지 않는 패치는 전달하지 않음 (스냅샷 재동기화 후 resync-required)
+		if !s.applyRemotePatch(message.UserId, message.Revision, message.Ops) {
+			return
+		}
+	case "role-updated":
+		s.applyRoleChange(message.TargetUserId, message.Role)
+	case "item-locked", "item-unlocked":
+		s.applyRemoteLock(message)
+	case "recording-state":
+		s.applyRemoteRecording(message)
+	case "viewport-update":
+		// follow 중인 로컬 클라이언트에게만 전달
+		s.applyRemoteViewport(message, envelope.Exclude, envelope.Payload)
+		return
+	case "presence-batch":
+		// 다음 tick에 로컬 커서/선택과 합쳐서 전달
+		s.applyRemotePresence(message)
 		return
 	}
 
-	for userId, client := range s.clients {
-		select {
-		case client.send <- messageBytes:
-			if message.Type == "history_visibility_update" {
-				log.Printf("📤 Sent history_visibility_update to user %s (showCreationHistory: %v, productions: %d)",
-					userId, message.ShowCreationHistory, len(message.HostProductions))
-			} else {
-				log.Printf("Sent message type '%s' to user %s", message.Type, userId)
-			}
-		default:
-			close(client.send)
-			delete(s.clients, userId)
+	if message.Seq > 0 {
+		s.recordReplay(message.Seq, envelope.Exclude, envelope.Payload)
+	} else if !ephemeralMessageTypes[message.Type] {
+		// 발행한 인스턴스에서 순번 발급에 실패한 메시지
+		s.markSeqGap()
+	}
+
+	s.deliverLocal(envelope.Exclude, coalesceKey(&message), envelope.Payload)
+
+	// 관리자 조치는 전달 후 로컬 연결에도 적용
+	switch message.Type {
+	case "user-kicked":
+		s.disconnectLocal(message.TargetUserId)
+	case "room-closed":
+		// 수신 루프(RoomBus.run)를 막지 않도록 별도 고루틴에서 정리
+		go sessionManager.closeSession(s.id)
+	}
+}
+
+// sync-nodes 데이터로 Room 상태 덮어쓰기 (remoteRevision은 다른 인스턴스에서 부여한 revision, 로컬이면 0)
+func (s *Session) applyNodesSync(userId string, data map[string]interface{}, remoteRevision int64) (int64, int, int, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	// 전체 교체는 이전 revision 기준 패치를 모두 무효화
+	if remoteRevision == 0 {
+		revision, err := s.allocateRevisionLocked(true)
+		if err != nil {
+			return s.revision, len(s.nodes), len(s.edges), err
+		}
+		s.revision = revision
+	} else if remoteRevision > s.revision {
+		s.revision = remoteRevision
+	}
+	s.fullSyncRevision = s.revision
+	// 놓친 패치까지 전체 교체로 따라잡음
+	if s.resyncTarget > 0 && s.revision >= s.resyncTarget {
+		s.resyncTarget = 0
+	}
+
+	if data != nil {
+		var changes []elementChange
+		if nodes, ok := data["nodes"].([]interface{}); ok {
+			changes = append(changes, diffGraph(targetNode, s.nodes, nodes)...)
+			s.nodes = nodes
+		}
+		if edges, ok := data["edges"].([]interface{}); ok {
+			changes = append(changes, diffGraph(targetEdge, s.edges, edges)...)
+			s.edges = edges
+		}
+		// 되돌리기 기록은 요청을 받은 인스턴스에서만 관리
+		if remoteRevision == 0 {
+			s.recordHistoryLocked(userId, changes)
 		}
 	}
+	s.lastSyncBy = userId
+	s.lastSyncAt = time.Now()
+	s.lastActivity = s.lastSyncAt
+	return s.revision, len(s.nodes), len(s.edges), nil
 }
 
 // 빈 세션 정리
@@ -263,12 +432,14 @@ func (sm *SessionManager) cleanupEmptySessions() {
 
 	cleaned := 0
 	for sessionId, session := range sm.sessions {
-		session.mutex.RLock()
-		isEmpty := len(session.clients) == 0
-		session.mutex.RUnlock()
-
-		if isEmpty {
+		// 비어있는지 확인과 종료를 hub에서 함께 처리 (동시에 접속하는 클라이언트와 경합 방지)
+		if session.stop(false) {
+			// 저장 대기 중인 상태가 있으면 정리 전에 저장
+			if session.hasPendingSnapshot() {
+				go session.flushSnapshot()
+			}
 			delete(sm.sessions, sessionId)
+			roomBus.unsubscribe(sessionId)
 			cleaned++
 
 			// 메트릭 업데이트
@@ -298,19 +469,22 @@ func (sm *SessionManager) cleanupExpiredSessions() {
 	for sessionId, session := range sm.sessions {
 		session.mutex.RLock()
 		isExpired := now.Sub(session.createdAt) > expiredThreshold
-		isInactive := now.Sub(session.lastActivity) > inactiveThreshold && len(session.clients) == 0
+		lastActivity := session.lastActivity
+		isInactive := now.Sub(lastActivity) > inactiveThreshold && len(session.clients) == 0
 		session.mutex.RUnlock()
 
 		if isExpired || isInactive {
-			// 연결된 클라이언트들 정리
-			session.mutex.Lock()
-			for userId, client := range session.clients {
-				close(client.send)
-				log.Printf("Disconnecting client %s from expired session %s", userId, sessionId)
+			// 연결된 클라이언트들 정리 (hub가 send 채널을 닫음)
+			if clientCount := session.clientCount(); clientCount > 0 {
+				log.Printf("Disconnecting %d clients from expired session %s", clientCount, sessionId)
 			}
-			session.mutex.Unlock()
+			session.stop(true)
 
+			if session.hasPendingSnapshot() {
+				go session.flushSnapshot()
+			}
 			delete(sm.sessions, sessionId)
+			roomBus.unsubscribe(sessionId)
 			cleaned++
 
 			// 메트릭 업데이트
@@ -323,7 +497,7 @@ func (sm *SessionManager) cleanupExpiredSessions() {
 				reason = "inactive"
 			}
 			log.Printf("⏰ Cleaned up %s session: %s (Age: %v, Inactive: %v)",
-				reason, sessionId, now.Sub(session.createdAt), now.Sub(session.lastActivity))
+				reason, sessionId, now.Sub(session.createdAt), now.Sub(lastActivity))
 		}
 	}
 
@@ -354,27 +528,63 @@ func (sm *SessionManager) startCleanupRoutine() {
 		}
 	}()
 
-	log.Printf("Started session cleanup routines (Empty: 5min, Expired: 30min)")
+	// 만료된 아이템 잠금 해제
+	go func() {
+		ticker := time.NewTicker(itemLockSweepInterval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			sm.expireItemLocks()
+		}
+	}()
+
+	log.Printf("Started session cleanup routines (Empty: 5min, Expired: 30min, Item locks: %v)", itemLockSweepInterval)
 }
 
 // WebSocket 핸들러
 func handleWebSocket(w http.ResponseWriter, r *http.Request) {
-	// WebSocket 연결 업그레이드
-	conn, err := upgrader.Upgrade(w, r, nil)
-	if err != nil {
-		log.Printf("WebSocket upgrade failed: %v", err)
-		return
-	}
-
 	// URL 파라미터 추출
 	orgId := r.URL.Query().Get("org_id")
 	workspaceId := r.URL.Query().Get("workspace_id")
 	userId := r.URL.Query().Get("user_id")
 	userName := r.URL.Query().Get("user_name")
+	requestedRole := r.URL.Query().Get("role") // 낮은 권한으로 참여할 때 (예: 리뷰어 viewer)
+	var userInfo map[string]interface{}
+
+	// WS_AUTH_DISABLED=true일 때만 요청 권한을 그대로 사용 (개발용)
+	baseRole := normalizeRole(requestedRole)
+	if baseRole == "" {
+		baseRole = roleEditor
+	}
+
+	// 인증 (업그레이드 전) - 사용자 ID는 토큰 클레임에서만 가져옴
+	if wsAuth != nil {
+		if orgId == "" || workspaceId == "" {
+			http.Error(w, `{"error": "org_id and workspace_id are required"}`, http.StatusBadRequest)
+			return
+		}
+
+		identity, status, err := wsAuth.authenticate(r, orgId, workspaceId)
+		if err != nil {
+			log.Printf("🚫 [WebSocket] Rejected connection to %s:%s: %v", orgId, workspaceId, err)
+			http.Error(w, fmt.Sprintf(`{"error": %q}`, err.Error()), status)
+			return
+		}
+
+		if userId != "" && userId != identity.UserId {
+			log.Printf("⚠️ [WebSocket] user_id %s does not match token subject %s - using token", userId, identity.UserId)
+		}
+		userId = identity.UserId
+		if identity.UserName != "" {
+			userName = identity.UserName
+		}
+		userInfo = map[string]interface{}{"email": identity.Email}
+		baseRole = roleFromMembership(identity.MemberRole)
+	}
 
 	if orgId == "" || workspaceId == "" || userId == "" {
 		log.Printf("❌ Missing required parameters (org_id, workspace_id, user_id)")
-		conn.Close()
+		http.Error(w, `{"error": "org_id, workspace_id and user_id are required"}`, http.StatusBadRequest)
 		return
 	}
 
@@ -385,6 +595,20 @@ func handleWebSocket(w http.ResponseWriter, r *http.Request) {
 	// Room 키 생성 (org_id:workspace_id)
 	roomKey := orgId + ":" + workspaceId
 
+	// 최근 강퇴된 사용자는 재접속 거부
+	if isRoomUserDenied(roomKey, userId) {
+		log.Printf("🚫 [WebSocket] %s was kicked from room %s - rejecting reconnect", userId, roomKey)
+		http.Error(w, `{"error": "kicked from room"}`, http.StatusForbidden)
+		return
+	}
+
+	// WebSocket 연결 업그레이드
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Printf("WebSocket upgrade failed: %v", err)
+		return
+	}
+
 	// 클라이언트 생성
 	client := &Client{
 		conn:        conn,
@@ -392,22 +616,28 @@ func handleWebSocket(w http.ResponseWriter, r *http.Request) {
 		workspaceId: workspaceId,
 		userId:      userId,
 		userName:    userName,
-		send:        make(chan []byte, 1024), // 버퍼 크기 증가 (256 → 1024)
+		userInfo:    userInfo,
+		outbox:      newClientOutbox(),
 	}
 
 	log.Printf("✅ [WebSocket] New connection - Org: %s, Workspace: %s, User: %s (%s)", orgId, workspaceId, userName, userId)
 
-	// Room에 클라이언트 추가
-	session := sessionManager.getOrCreateSession(roomKey)
+	// Room에 클라이언트 추가 (정리 중인 세션이면 새 세션으로 재시도)
+	var session *Session
+	for {
+		session = sessionManager.getOrCreateSession(roomKey)
 
-	// 현재 Room의 사용자 수 확인
-	session.mutex.RLock()
-	existingUsers := len(session.clients)
-	session.mutex.RUnlock()
+		// 권한 결정 (호스트 지정 권한 > 요청 권한 > 멤버십 권한)
+		client.setRole(resolveRole(baseRole, requestedRole, session.roleOverride(userId)))
+		log.Printf("🔑 [WebSocket] %s joins room %s as %s", userId, roomKey, client.getRole())
 
-	log.Printf("📊 [WebSocket] Room %s has %d existing users", roomKey, existingUsers)
+		// 현재 Room의 사용자 수 확인
+		log.Printf("📊 [WebSocket] Room %s has %d existing users", roomKey, session.clientCount())
 
-	session.addClient(client)
+		if session.addClient(client) {
+			break
+		}
+	}
 
 	// 고루틴으로 읽기/쓰기 처리
 	go client.writePump()
@@ -424,7 +654,7 @@ const (
 // 클라이언트로부터 메시지 읽기
 func (c *Client) readPump(session *Session) {
 	defer func() {
-		session.removeClient(c.userId)
+		session.removeClient(c)
 		c.conn.Close()
 	}()
 
@@ -445,6 +675,31 @@ func (c *Client) readPump(session *Session) {
 			break
 		}
 
+		// 권한 확인 (viewer는 상태 변경 불가, 권한 변경은 host만)
+		if !c.canSend(message.Type) {
+			c.sendMessage(Message{
+				Type:   "error",
+				Reason: "forbidden",
+				Role:   c.getRole(),
+				Data:   map[string]interface{}{"messageType": message.Type},
+			})
+			log.Printf("🚫 [WebSocket] %s (%s) is not allowed to send %s", c.userId, c.getRole(), message.Type)
+			continue
+		}
+
+		// 다른 사용자가 잠근 아이템 변경 거부
+		if lockedUpdateTypes[message.Type] {
+			if locked := session.checkItemLocks(c.userId, messageItemIds(&message)); len(locked) > 0 {
+				c.sendMessage(Message{
+					Type:      "error",
+					Reason:    "item locked",
+					Conflicts: locked,
+					Data:      map[string]interface{}{"messageType": message.Type},
+				})
+				continue
+			}
+		}
+
 		// 메시지 타입에 따른 처리
 		switch message.Type {
 		case "user_selection":
@@ -487,68 +742,222 @@ func (c *Client) readPump(session *Session) {
 		// Visual Editor 협업 메시지 타입 (신규)
 		case "request-state":
 			log.Printf("📥 [WebSocket] User %s (%s) requested initial state", c.userName, c.userId)
-
-			// Room에 저장된 상태 읽기
-			session.mutex.RLock()
-			nodes := session.nodes
-			edges := session.edges
-			lastSyncBy := session.lastSyncBy
-			lastSyncAt := session.lastSyncAt
-			session.mutex.RUnlock()
-
-			// 초기 상태 응답
-			initialState := Message{
-				Type: "initial-state",
-				Data: map[string]interface{}{
-					"nodes":      nodes,
-					"edges":      edges,
-					"lastSyncBy": lastSyncBy,
-					"lastSyncAt": lastSyncAt,
-				},
-				OrgId:       c.orgId,
-				WorkspaceId: c.workspaceId,
-			}
-
-			// 요청한 사용자에게만 전송
-			if stateBytes, err := json.Marshal(initialState); err == nil {
-				select {
-				case c.send <- stateBytes:
-					log.Printf("✅ [WebSocket] Sent initial state to %s (%d nodes, %d edges)",
-						c.userName, len(nodes), len(edges))
-				default:
-					log.Printf("⚠️ [WebSocket] Failed to send initial state to %s (channel full)", c.userName)
-				}
-			}
+			session.sendInitialState(c, false)
 
 			// 이 메시지는 브로드캐스트하지 않음 (continue로 건너뜀)
 			continue
 
+		case "resume":
+			// 재접속 클라이언트: 마지막으로 받은 순번 이후 메시지 재전송
+			session.resume(c, message.Seq)
+			continue
+
 		case "sync-nodes":
 			// Room 상태 업데이트
 			if message.Data != nil {
-				session.mutex.Lock()
-				if nodes, ok := message.Data["nodes"].([]interface{}); ok {
-					session.nodes = nodes
-				}
-				if edges, ok := message.Data["edges"].([]interface{}); ok {
-					session.edges = edges
+				revision, nodeCount, edgeCount, err := session.applyNodesSync(c.userId, message.Data, 0)
+				if err != nil {
+					log.Printf("❌ [WebSocket] Failed to sync state from %s: %v", c.userId, err)
+					c.sendMessage(Message{
+						Type:     "error",
+						Reason:   err.Error(),
+						Revision: revision,
+						Data:     map[string]interface{}{"messageType": message.Type},
+					})
+					continue
 				}
-				session.lastSyncBy = c.userId
-				session.lastSyncAt = time.Now()
-				nodeCount := len(session.nodes)
-				edgeCount := len(session.edges)
-				session.mutex.Unlock()
-
-				log.Printf("📤 [WebSocket] User %s (%s) synced state (%d nodes, %d edges)",
-					c.userName, c.userId, nodeCount, edgeCount)
+				session.scheduleSnapshot()
+				message.Revision = revision
+
+				log.Printf("📤 [WebSocket] User %s (%s) synced state (%d nodes, %d edges, rev %d)",
+					c.userName, c.userId, nodeCount, edgeCount, revision)
 			}
 
 			// 메시지에 발신자 정보 추가
+			message.UserId = c.userId
 			message.OrgId = c.orgId
 			message.WorkspaceId = c.workspaceId
 			message.UserName = c.userName
 			message.Type = "nodes-updated" // 브로드캐스트용 타입 변경
 
+		case "patch-nodes":
+			// 델타 동기화: 기준 revision 위에 노드/엣지 연산 적용
+			// 다른 사용자가 잠근 노드를 건드리는 패치는 적용하지 않음
+			var revision int64
+			var applied []graphOp
+			var err error
+			if locked := session.lockedPatchNodes(c.userId, message.Ops); len(locked) > 0 {
+				revision = session.currentRevision()
+				err = &patchConflictError{reason: "item locked", conflicts: locked}
+			} else {
+				revision, applied, err = session.applyPatch(c.userId, message.BaseRevision, message.Ops)
+			}
+			if err != nil {
+				rejected := Message{
+					Type:         "patch-rejected",
+					Revision:     revision,
+					BaseRevision: message.BaseRevision,
+					Reason:       err.Error(),
+				}
+				if conflictErr, ok := err.(*patchConflictError); ok {
+					rejected.Conflicts = conflictErr.conflicts
+				}
+				c.sendMessage(rejected)
+				log.Printf("⚠️ [WebSocket] Rejected patch from %s (base %d, current %d): %v",
+					c.userId, message.BaseRevision, revision, err)
+				continue
+			}
+			session.scheduleSnapshot()
+
+			c.sendMessage(Message{
+				Type:         "patch-ack",
+				Revision:     revision,
+				BaseRevision: message.BaseRevision,
+			})
+
+			// 적용된 연산만 다른 사용자에게 전달
+			message = Message{
+				Type:         "nodes-patched",
+				UserId:       c.userId,
+				UserName:     c.userName,
+				OrgId:        c.orgId,
+				WorkspaceId:  c.workspaceId,
+				Revision:     revision,
+				BaseRevision: message.BaseRevision,
+				Ops:          applied,
+			}
+
+		case "undo", "redo":
+			// 서버 기록 기반 되돌리기/다시 실행 (다른 사용자가 이후에 바꾼 요소는 건너뜀)
+			redo := message.Type == "redo"
+			revision, applied, conflicts, err := session.undoRedo(c.userId, redo)
+			undoDepth, redoDepth := session.historyDepth(c.userId)
+			if err != nil {
+				c.sendMessage(Message{
+					Type:      "error",
+					Reason:    err.Error(),
+					Revision:  revision,
+					Conflicts: conflicts,
+					Data:      map[string]interface{}{"messageType": message.Type},
+				})
+				c.sendMessage(Message{
+					Type: "history-state",
+					Data: map[string]interface{}{"undo": undoDepth, "redo": redoDepth},
+				})
+				continue
+			}
+			session.scheduleSnapshot()
+			log.Printf("↩️ [WebSocket] User %s %s applied %d ops (rev %d, %d conflicts)",
+				c.userId, message.Type, len(applied), revision, len(conflicts))
+
+			// 요청자도 연산을 적용해야 하므로 모든 사용자에게 전송
+			session.broadcastToAll(Message{
+				Type:        "nodes-patched",
+				UserId:      c.userId,
+				UserName:    c.userName,
+				OrgId:       c.orgId,
+				WorkspaceId: c.workspaceId,
+				Revision:    revision,
+				Ops:         applied,
+				Conflicts:   conflicts,
+				Data:        map[string]interface{}{"source": message.Type},
+			})
+			c.sendMessage(Message{
+				Type: "history-state",
+				Data: map[string]interface{}{"undo": undoDepth, "redo": redoDepth},
+			})
+			continue
+
+		case "lock_item":
+			// 드래그/편집 시작: 아이템 잠금 (본인이 다시 보내면 lease 갱신)
+			if message.ItemId == "" {
+				c.sendMessage(Message{
+					Type:   "error",
+					Reason: "missing itemId",
+					Data:   map[string]interface{}{"messageType": message.Type},
+				})
+				continue
+			}
+			lock, ok := session.lockItem(message.ItemId, c.userId, c.userName)
+			if !ok {
+				c.sendMessage(Message{
+					Type:     "lock-rejected",
+					ItemId:   message.ItemId,
+					UserId:   lock.UserId,
+					UserName: lock.UserName,
+					Reason:   "item locked",
+					Data:     map[string]interface{}{"expiresAt": lock.ExpiresAt},
+				})
+				continue
+			}
+
+			message = Message{
+				Type:        "item-locked",
+				ItemId:      message.ItemId,
+				UserId:      c.userId,
+				UserName:    c.userName,
+				OrgId:       c.orgId,
+				WorkspaceId: c.workspaceId,
+				Data:        map[string]interface{}{"expiresAt": lock.ExpiresAt},
+			}
+
+		case "unlock_item":
+			// 드래그/편집 종료
+			if !session.unlockItem(message.ItemId, c.userId) {
+				continue
+			}
+			session.broadcastUnlocked(message.ItemId, c.userId, c.userName, "released")
+			continue
+
+		case "comment_create", "comment_reply", "comment_resolve":
+			// 코멘트 스레드 (Supabase 저장 후 모두에게 브로드캐스트, viewer도 작성 가능)
+			session.handleComment(c, message)
+			continue
+
+		case "viewport-update":
+			// 호스트 화면 위치 (pan/zoom) → follow 중인 클라이언트에게만 전달
+			if err := session.updateViewport(c, message); err != nil {
+				c.sendMessage(Message{
+					Type:   "error",
+					Reason: err.Error(),
+					Data:   map[string]interface{}{"messageType": message.Type},
+				})
+			}
+			continue
+
+		case "follow", "unfollow":
+			session.handleFollow(c, message.Type == "follow")
+			continue
+
+		case "recording-start", "recording-stop":
+			// 호스트가 Room 녹화 시작/종료 (Redis Stream, 재생은 /recordings/{id}/play)
+			session.handleRecording(c, message)
+			continue
+
+		case "set-role":
+			// 호스트가 참여자 권한 변경 (승격/강등)
+			role := normalizeRole(message.Role)
+			if message.TargetUserId == "" || role == "" {
+				c.sendMessage(Message{
+					Type:   "error",
+					Reason: "invalid role change",
+					Data:   map[string]interface{}{"messageType": message.Type},
+				})
+				continue
+			}
+			session.applyRoleChange(message.TargetUserId, role)
+			log.Printf("🔑 [WebSocket] Host %s set %s to %s in room %s", c.userId, message.TargetUserId, role, session.id)
+
+			message = Message{
+				Type:         "role-updated",
+				UserId:       c.userId,
+				UserName:     c.userName,
+				OrgId:        c.orgId,
+				WorkspaceId:  c.workspaceId,
+				TargetUserId: message.TargetUserId,
+				Role:         role,
+			}
+
 		case "cursor-update":
 			// 커서 업데이트는 로깅하지 않음 (성능)
 			message.OrgId = c.orgId
@@ -575,17 +984,24 @@ func (c *Client) readPump(session *Session) {
 			session.broadcastToOthers(c.userId, leaveMessage)
 
 			// 클라이언트 제거 및 연결 종료
-			session.removeClient(c.userId)
+			session.removeClient(c)
 			c.conn.Close()
 			return // readPump 종료
 		}
 
+		// 커서/선택은 즉시 브로드캐스트하지 않고 presence tick에 모아서 전송
+		if ephemeralMessageTypes[message.Type] {
+			message.UserId = c.userId
+			session.queuePresence(message, false)
+			continue
+		}
+
 		// 메시지 타입에 따라 브로드캐스트 방식 결정
 		switch message.Type {
-		case "user_joined", "request_canvas_state", "user_left":
+		case "user_joined", "request_canvas_state", "user_left", "role-updated", "item-locked":
 			// 이 메시지들은 모든 사용자에게 전송 (호스트 포함)
 			session.broadcastToAll(message)
-		case "nodes-updated", "cursor-update", "selection-update":
+		case "nodes-updated", "nodes-patched":
 			// Visual Editor 협업 메시지는 자신을 제외한 다른 사용자에게만 전송
 			session.broadcastToOthers(c.userId, message)
 		default:
@@ -595,6 +1011,60 @@ func (c *Client) readPump(session *Session) {
 	}
 }
 
+// 요청한 사용자에게 Room 상태 전송 (resync: 재접속 재전송 불가로 보내는 경우)
+func (s *Session) sendInitialState(c *Client, resync bool) {
+	// Room에 저장된 상태 읽기
+	s.mutex.RLock()
+	nodes := s.nodes
+	edges := s.edges
+	lastSyncBy := s.lastSyncBy
+	lastSyncAt := s.lastSyncAt
+	revision := s.revision
+	seq := s.seq
+	s.mutex.RUnlock()
+	locks := s.activeLocks()
+	viewport := s.currentViewport()
+
+	// 초기 상태 응답
+	initialState := Message{
+		Type: "initial-state",
+		Data: map[string]interface{}{
+			"nodes":        nodes,
+			"edges":        edges,
+			"lastSyncBy":   lastSyncBy,
+			"lastSyncAt":   lastSyncAt,
+			"revision":     revision,
+			"role":         c.getRole(),
+			"resync":       resync,
+			"locks":        locks,
+			"hostViewport": viewport,
+		},
+		OrgId:       c.orgId,
+		WorkspaceId: c.workspaceId,
+		Seq:         seq,
+	}
+
+	if c.sendMessage(initialState) {
+		log.Printf("✅ [WebSocket] Sent initial state to %s (%d nodes, %d edges, seq %d)",
+			c.userName, len(nodes), len(edges), seq)
+	}
+}
+
+// 이 클라이언트에게만 메시지 전송 (응답/에러 프레임용)
+func (c *Client) sendMessage(message Message) bool {
+	messageBytes, err := json.Marshal(message)
+	if err != nil {
+		log.Printf("Error marshaling message: %v", err)
+		return false
+	}
+
+	if !c.trySend(messageBytes) {
+		log.Printf("⚠️ [WebSocket] Failed to send %s to %s (channel full or closed)", message.Type, c.userId)
+		return false
+	}
+	return true
+}
+
 // 클라이언트로 메시지 쓰기
 func (c *Client) writePump() {
 	ticker := time.NewTicker(pingPeriod)
@@ -605,16 +1075,19 @@ func (c *Client) writePump() {
 
 	for {
 		select {
-		case message, ok := <-c.send:
-			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
-			if !ok {
-				// 채널이 닫혔으면 연결 종료
-				c.conn.WriteMessage(websocket.CloseMessage, []byte{})
-				return
+		case <-c.outbox.ready:
+			messages, closed := c.outbox.take()
+			for _, message := range messages {
+				c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+				if err := c.conn.WriteMessage(websocket.TextMessage, message); err != nil {
+					log.Printf("WebSocket write error: %v", err)
+					return
+				}
 			}
-
-			if err := c.conn.WriteMessage(websocket.TextMessage, message); err != nil {
-				log.Printf("WebSocket write error: %v", err)
+			if closed {
+				// 대기열이 닫혔으면 연결 종료
+				c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+				c.conn.WriteMessage(websocket.CloseMessage, []byte{})
 				return
 			}
 
@@ -677,6 +1150,7 @@ func getSessionInfo(w http.ResponseWriter, r *http.Request) {
 	for userId := range session.clients {
 		clientIds = append(clientIds, userId)
 	}
+	lastActivity := session.lastActivity
 	session.mutex.RUnlock()
 
 	w.Header().Set("Content-Type", "application/json")
@@ -684,10 +1158,11 @@ func getSessionInfo(w http.ResponseWriter, r *http.Request) {
 		"sessionId":    sessionId,
 		"clientCount":  clientCount,
 		"clients":      clientIds,
+		"participants": roomBus.participants(sessionId), // 전체 인스턴스 기준 (Redis 미사용 시 null)
 		"createdAt":    session.createdAt,
-		"lastActivity": session.lastActivity,
+		"lastActivity": lastActivity,
 		"age":          time.Since(session.createdAt).String(),
-		"inactive":     time.Since(session.lastActivity).String(),
+		"inactive":     time.Since(lastActivity).String(),
 	})
 }
 
@@ -758,6 +1233,47 @@ func main() {
 		log.Fatalf("Failed to load config: %v", err)
 	}
 
+	// WebSocket 인증 (WS_AUTH_DISABLED=true가 아니면 필수)
+	cfg := config.GetConfig()
+	auth, err := newWSAuthenticator(cfg)
+	if err != nil {
+		log.Fatalf("❌ Failed to initialize WebSocket authentication: %v", err)
+	}
+	wsAuth = auth
+	if len(cfg.WSAllowedOrigins) == 0 {
+		if cfg.WSAuthDisabled {
+			log.Println("⚠️ WS_ALLOWED_ORIGINS not set - accepting WebSocket connections from any origin (WS_AUTH_DISABLED)")
+		} else {
+			log.Println("⚠️ WS_ALLOWED_ORIGINS not set - rejecting browser WebSocket connections")
+		}
+	}
+
+	// Room Pub/Sub 팬아웃 (Redis 연결 실패 시 단일 인스턴스 모드)
+	// 같은 연결 풀을 Job 상태/dead-letter/웹훅 API도 함께 사용
+	rdb := redisClient.Connect(cfg)
+	if rdb != nil {
+		roomBus = newRoomBus(rdb, cfg.InstanceID)
+		snapshotStore = newSnapshotStore(rdb, cfg)
+	} else {
+		log.Println("⚠️ Room fan-out and snapshots disabled - running in single-instance mode")
+	}
+
+	// 재접속 재전송 버퍼 크기, 아이템 잠금 lease, presence tick, 녹화 보관
+	replayBufferSize = cfg.RoomReplayBuffer
+	itemLockLease = cfg.ItemLockLease
+	presenceTickInterval = cfg.PresenceTick
+	recordingTTL = cfg.RoomRecordingTTL
+	recordingMaxEvents = cfg.RoomRecordingMaxEvents
+
+	// Room 관리자 API (ADMIN_API_TOKEN 없으면 비활성화)
+	adminToken = cfg.AdminAPIToken
+	if adminToken == "" {
+		log.Println("⚠️ ADMIN_API_TOKEN not set - room admin API disabled")
+	}
+
+	// 캔버스 코멘트 스레드 (Supabase 미설정 시 비활성화)
+	commentStore = newCommentStore(cfg)
+
 	// 정리 루틴 시작
 	sessionManager.startCleanupRoutine()
 
@@ -787,7 +1303,10 @@ func main() {
 	r.HandleFunc("/ws", handleWebSocket)
 	r.HandleFunc("/session/{sessionId}", getSessionInfo).Methods("GET")
 	r.HandleFunc("/metrics", getMetrics).Methods("GET")
-	r.HandleFunc("/admin/cleanup", forceCleanupSessions).Methods("POST")
+	r.HandleFunc("/admin/cleanup", requireAdminIfConfigured(forceCleanupSessions)).Methods("POST")
+	registerAdminRoutes(r)
+	registerCommentRoutes(r)
+	registerRecordingRoutes(r)
 
 	// Modify 모듈 라우트 등록
 	modifyHandler := modify.NewModifyHandler()
@@ -821,6 +1340,30 @@ func main() {
 		log.Println("⚠️ Failed to initialize Enqueue handler - check Redis connection")
 	}
 
+	// Job 상태/대기 순서 조회 API 라우트 등록
+	jobStatusHandler := worker.NewJobStatusHandler(rdb)
+	if jobStatusHandler != nil {
+		jobStatusHandler.RegisterRoutes(r)
+	} else {
+		log.Println("⚠️ Failed to initialize Job status handler - check Redis connection")
+	}
+
+	// Dead-letter 관리자 API 라우트 등록 (ADMIN_API_TOKEN 인증)
+	deadLetterHandler := worker.NewDeadLetterHandler(rdb)
+	if deadLetterHandler != nil {
+		deadLetterHandler.RegisterRoutes(r, requireAdmin)
+	} else {
+		log.Println("⚠️ Failed to initialize Dead-letter handler - check Redis connection")
+	}
+
+	// Job 완료 웹훅 관리자 API 라우트 등록 (ADMIN_API_TOKEN 인증)
+	webhookHandler := worker.NewWebhookHandler(rdb)
+	if webhookHandler != nil {
+		webhookHandler.RegisterRoutes(r, requireAdmin)
+	} else {
+		log.Println("⚠️ Failed to initialize Webhook handler - check Redis connection")
+	}
+
 	// Unified Prompt - Landing 라우트 등록
 	landingHandler := landing.NewHandler()
 	if landingHandler != nil {
@@ -906,6 +1449,7 @@ func main() {
 	log.Printf("Health check: http://localhost:%s/health", port)
 	log.Printf("Metrics: http://localhost:%s/metrics", port)
 	log.Printf("Admin cleanup: http://localhost:%s/admin/cleanup", port)
+	log.Printf("Admin rooms: http://localhost:%s/admin/rooms/{roomKey}/participants", port)
 	log.Printf("Modify submit: http://localhost:%s/api/modify/submit", port)
 	log.Printf("Modify status: http://localhost:%s/api/modify/status/{jobId}", port)
 	log.Printf("Job cancel: http://localhost:%s/api/jobs/{jobId}/cancel", port)
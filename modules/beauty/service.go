@@ -26,6 +26,7 @@ import (
 
 	"quel-canvas-server/modules/common/config"
 	geminiretry "quel-canvas-server/modules/common/gemini"
+	"quel-canvas-server/modules/common/jobevents"
 	"quel-canvas-server/modules/common/model"
 	"quel-canvas-server/modules/common/org"
 	redisutil "quel-canvas-server/modules/common/redis"
@@ -132,6 +133,7 @@ func (s *Service) UpdateJobStatus(ctx context.Context, jobID string, status stri
 	}
 
 	log.Printf("✅ Job %s status updated to: %s", jobID, status)
+	jobevents.JobStatus(jobID, status)
 	return nil
 }
 
@@ -1036,6 +1038,7 @@ func (s *Service) CreateAttachRecord(ctx context.Context, filePath string, fileS
 	attachID := int(attaches[0].AttachID)
 	log.Printf("✅ Attach record created: ID=%d", attachID)
 
+	jobevents.AttachCreated(attachID, filePath)
 	return attachID, nil
 }
 
@@ -1059,6 +1062,7 @@ func (s *Service) UpdateJobProgress(ctx context.Context, jobID string, completed
 	}
 
 	log.Printf("✅ Job progress updated: %d images completed", completedImages)
+	jobevents.ImageDone(jobID, completedImages, generatedAttachIds)
 	return nil
 }
 
@@ -1114,6 +1118,7 @@ func (s *Service) UpdateProductionAttachIds(ctx context.Context, productionID st
 	}
 
 	log.Printf("✅ Production attach_ids updated: %v", mergedIds)
+	jobevents.ProductionAttached(productionID, newAttachIds)
 	return nil
 }
 
@@ -15,6 +15,7 @@ import (
 	"quel-canvas-server/modules/common/cancel"
 	"quel-canvas-server/modules/common/config"
 	"quel-canvas-server/modules/common/fallback"
+	"quel-canvas-server/modules/common/jobevents"
 	"quel-canvas-server/modules/common/model"
 )
 
@@ -278,7 +279,7 @@ func processSingleBatch(ctx context.Context, service *Service, job *model.Produc
 			// 해당 조합의 quantity만큼 생성
 			for i := 0; i < quantity; i++ {
 				// 🛑 취소 체크 - 새 이미지 생성 전에 확인
-				if service.IsJobCancelled(job.JobID) {
+				if cancel.JobCancelled(ctx, service, job.JobID) {
 					log.Printf("🛑 Combination %d: Job %s cancelled, stopping generation", idx+1, job.JobID)
 					service.UpdateJobStatus(ctx, job.JobID, model.StatusUserCancelled)
 					if job.ProductionID != nil {
@@ -312,7 +313,7 @@ func processSingleBatch(ctx context.Context, service *Service, job *model.Produc
 				}
 
 				// 🛑 Gemini 응답 후 취소 체크 - 취소됐으면 저장/차감 안 함
-				if service.IsJobCancelled(job.JobID) {
+				if cancel.JobCancelled(ctx, service, job.JobID) {
 					log.Printf("🛑 Combination %d: Job %s cancelled after generation, discarding image %d", idx+1, job.JobID, i+1)
 					service.UpdateJobStatus(ctx, job.JobID, model.StatusUserCancelled)
 					if job.ProductionID != nil {
@@ -363,6 +364,13 @@ func processSingleBatch(ctx context.Context, service *Service, job *model.Produc
 				log.Printf("✅ Combination %d: Image %d/%d completed for [%s + %s]: AttachID=%d",
 					idx+1, i+1, quantity, angle, shot, attachID)
 
+				// SSE/Room 이벤트에 조합 정보 포함
+				jobevents.DescribeImage(attachID, map[string]interface{}{
+					"combination": idx + 1,
+					"angle":       angle,
+					"shot":        shot,
+				})
+
 				// 진행 상황 업데이트
 				if err := service.UpdateJobProgress(ctx, job.JobID, currentProgress, currentAttachIds); err != nil {
 					log.Printf("⚠️  Failed to update progress: %v", err)
@@ -382,7 +390,7 @@ func processSingleBatch(ctx context.Context, service *Service, job *model.Produc
 	// ✅ Retry logic: TotalImages와 completedCount 비교 및 재시도
 	maxRetries := 10
 	retryAttempt := 0
-	cancelled := service.IsJobCancelled(job.JobID)
+	cancelled := cancel.JobCancelled(ctx, service, job.JobID)
 
 	for completedCount < job.TotalImages && retryAttempt < maxRetries && !cancelled {
 		retryAttempt++
@@ -410,7 +418,7 @@ func processSingleBatch(ctx context.Context, service *Service, job *model.Produc
 		}
 
 		// 🛑 Check cancellation before retry generation
-		if service.IsJobCancelled(job.JobID) {
+		if cancel.JobCancelled(ctx, service, job.JobID) {
 			log.Printf("🛑 Job %s cancelled during retry", job.JobID)
 			cancelled = true
 			break
@@ -438,7 +446,7 @@ func processSingleBatch(ctx context.Context, service *Service, job *model.Produc
 		}
 
 		// 🛑 Check cancellation after generation
-		if service.IsJobCancelled(job.JobID) {
+		if cancel.JobCancelled(ctx, service, job.JobID) {
 			log.Printf("🛑 Job %s cancelled after retry generation, discarding image", job.JobID)
 			cancelled = true
 			break
@@ -497,7 +505,7 @@ func processSingleBatch(ctx context.Context, service *Service, job *model.Produc
 		}
 
 		// Update cancelled status
-		cancelled = service.IsJobCancelled(job.JobID)
+		cancelled = cancel.JobCancelled(ctx, service, job.JobID)
 	}
 
 	// Final check and logging
@@ -510,7 +518,7 @@ func processSingleBatch(ctx context.Context, service *Service, job *model.Produc
 
 	// Phase 5: 최종 완료 처리
 	// 🛑 취소된 Job은 user_cancelled 상태 유지 (completed로 덮어쓰지 않음)
-	if service.IsJobCancelled(job.JobID) {
+	if cancel.JobCancelled(ctx, service, job.JobID) {
 		log.Printf("🛑 Job %s was cancelled, keeping user_cancelled status", job.JobID)
 		// attach_ids만 업데이트 (이미 생성된 이미지들)
 		if job.ProductionID != nil && len(generatedAttachIds) > 0 {
@@ -773,7 +781,7 @@ func processPipelineStage(ctx context.Context, service *Service, job *model.Prod
 
 			for i := 0; i < quantity; i++ {
 				// 🛑 취소 체크 - 새 이미지 생성 전에 확인
-				if service.IsJobCancelled(job.JobID) {
+				if cancel.JobCancelled(ctx, service, job.JobID) {
 					log.Printf("🛑 Stage %d: Job %s cancelled, stopping generation", stageIndex, job.JobID)
 					results[stageIndex] = cancel.StageResult{
 						StageIndex: stageIndex,
@@ -811,7 +819,7 @@ func processPipelineStage(ctx context.Context, service *Service, job *model.Prod
 				}
 
 				// 🛑 Gemini 응답 후 취소 체크 - 취소됐으면 저장/차감 안 함
-				if service.IsJobCancelled(job.JobID) {
+				if cancel.JobCancelled(ctx, service, job.JobID) {
 					log.Printf("🛑 Stage %d: Job %s cancelled after generation, discarding image %d", stageIndex, job.JobID, i+1)
 					results[stageIndex] = cancel.StageResult{
 						StageIndex: stageIndex,
@@ -919,7 +927,7 @@ func processPipelineStage(ctx context.Context, service *Service, job *model.Prod
 	// Step 2: 부족한 Stage만 재시도
 	for stageIdx, stageData := range stages {
 		// 🛑 재시도 전에 취소 체크
-		if service.IsJobCancelled(job.JobID) {
+		if cancel.JobCancelled(ctx, service, job.JobID) {
 			log.Printf("🛑 Job %s cancelled, skipping retry phase", job.JobID)
 			break
 		}
@@ -1000,7 +1008,7 @@ func processPipelineStage(ctx context.Context, service *Service, job *model.Prod
 		retrySuccess := 0
 		for i := 0; i < missing; i++ {
 			// 🛑 재시도 중 취소 체크
-			if service.IsJobCancelled(job.JobID) {
+			if cancel.JobCancelled(ctx, service, job.JobID) {
 				log.Printf("🛑 Stage %d: Job %s cancelled during retry", stageIdx, job.JobID)
 				service.UpdateJobStatus(ctx, job.JobID, model.StatusUserCancelled)
 				if job.ProductionID != nil {
@@ -1122,7 +1130,7 @@ func processPipelineStage(ctx context.Context, service *Service, job *model.Prod
 
 	// Phase 4: 최종 완료 처리
 	// 🛑 취소된 Job은 user_cancelled 상태 유지 (completed로 덮어쓰지 않음)
-	if service.IsJobCancelled(job.JobID) {
+	if cancel.JobCancelled(ctx, service, job.JobID) {
 		log.Printf("🛑 Job %s was cancelled, keeping user_cancelled status", job.JobID)
 		// attach_ids만 업데이트 (이미 생성된 이미지들)
 		if job.ProductionID != nil && len(allGeneratedAttachIds) > 0 {
@@ -1287,7 +1295,7 @@ func processSimpleGeneral(ctx context.Context, service *Service, job *model.Prod
 
 	for i := 0; i < quantity; i++ {
 		// 🛑 취소 체크
-		if service.IsJobCancelled(job.JobID) {
+		if cancel.JobCancelled(ctx, service, job.JobID) {
 			log.Printf("🛑 Job %s cancelled, stopping generation", job.JobID)
 			service.UpdateJobStatus(ctx, job.JobID, model.StatusUserCancelled)
 			if job.ProductionID != nil {
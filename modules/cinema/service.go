@@ -25,6 +25,7 @@ import (
 
 	"quel-canvas-server/modules/common/config"
 	geminiretry "quel-canvas-server/modules/common/gemini"
+	"quel-canvas-server/modules/common/jobevents"
 	"quel-canvas-server/modules/common/model"
 	"quel-canvas-server/modules/common/org"
 	redisutil "quel-canvas-server/modules/common/redis"
@@ -135,6 +136,7 @@ func (s *Service) UpdateJobStatus(ctx context.Context, jobID string, status stri
 	}
 
 	log.Printf("✅ Job %s status updated to: %s", jobID, status)
+	jobevents.JobStatus(jobID, status)
 	return nil
 }
 
@@ -793,6 +795,7 @@ func (s *Service) CreateAttachRecord(ctx context.Context, filePath string, fileS
 	attachID := int(attaches[0].AttachID)
 	log.Printf("✅ Attach record created: ID=%d", attachID)
 
+	jobevents.AttachCreated(attachID, filePath)
 	return attachID, nil
 }
 
@@ -831,6 +834,7 @@ func (s *Service) UpdateJobProgress(ctx context.Context, jobID string, completed
 	}
 
 	log.Printf("✅ Job progress updated: %d images completed", completedImages)
+	jobevents.ImageDone(jobID, completedImages, generatedAttachIds)
 	return nil
 }
 
@@ -886,6 +890,7 @@ func (s *Service) UpdateProductionAttachIds(ctx context.Context, productionID st
 	}
 
 	log.Printf("✅ Production attach_ids updated: %v", mergedIds)
+	jobevents.ProductionAttached(productionID, newAttachIds)
 	return nil
 }
 
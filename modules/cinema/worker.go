@@ -15,6 +15,7 @@ import (
 	"quel-canvas-server/modules/common/cancel"
 	"quel-canvas-server/modules/common/config"
 	"quel-canvas-server/modules/common/fallback"
+	"quel-canvas-server/modules/common/jobevents"
 	"quel-canvas-server/modules/common/model"
 )
 
@@ -314,7 +315,7 @@ MANDATORY TECHNICAL SPECS:
 			// 해당 조합의 quantity만큼 생성
 			for i := 0; i < quantity; i++ {
 				// 🛑 취소 체크 - 새 이미지 생성 전에 확인
-				if service.IsJobCancelled(job.JobID) {
+				if cancel.JobCancelled(ctx, service, job.JobID) {
 					log.Printf("🛑 Combination %d: Job %s cancelled, stopping generation", idx+1, job.JobID)
 					service.UpdateJobStatus(ctx, job.JobID, model.StatusUserCancelled)
 					if job.ProductionID != nil {
@@ -326,8 +327,8 @@ MANDATORY TECHNICAL SPECS:
 				log.Printf("🎨 Combination %d: Generating image %d/%d for [%s + %s]...",
 					idx+1, i+1, quantity, angle, shot)
 
-				// 병렬 처리 레이트 리밋 방지: 3초 딜레이
-				time.Sleep(3 * time.Second)
+				// 병렬 처리 레이트 리밋 방지: 3초 딜레이 (취소되면 바로 깨어남)
+				cancel.Sleep(ctx, 3*time.Second)
 
 				// Gemini API 호출 (카테고리별 이미지 전달, aspect-ratio 포함)
 				generatedBase64, err := service.GenerateImageWithGeminiMultiple(ctx, categories, enhancedPrompt, aspectRatio)
@@ -350,7 +351,7 @@ MANDATORY TECHNICAL SPECS:
 				}
 
 				// 🛑 Gemini 응답 후 취소 체크 - 취소됐으면 저장/차감 안 함
-				if service.IsJobCancelled(job.JobID) {
+				if cancel.JobCancelled(ctx, service, job.JobID) {
 					log.Printf("🛑 Combination %d: Job %s cancelled after generation, discarding image %d", idx+1, job.JobID, i+1)
 					service.UpdateJobStatus(ctx, job.JobID, model.StatusUserCancelled)
 					if job.ProductionID != nil {
@@ -401,6 +402,13 @@ MANDATORY TECHNICAL SPECS:
 				log.Printf("✅ Combination %d: Image %d/%d completed for [%s + %s]: AttachID=%d",
 					idx+1, i+1, quantity, angle, shot, attachID)
 
+				// SSE/Room 이벤트에 조합 정보 포함
+				jobevents.DescribeImage(attachID, map[string]interface{}{
+					"combination": idx + 1,
+					"angle":       angle,
+					"shot":        shot,
+				})
+
 				// 진행 상황 업데이트
 				if err := service.UpdateJobProgress(ctx, job.JobID, currentProgress, currentAttachIds); err != nil {
 					log.Printf("⚠️  Failed to update progress: %v", err)
@@ -422,7 +430,7 @@ MANDATORY TECHNICAL SPECS:
 	retryAttempt := 0
 	cancelled := false
 
-	for completedCount < job.TotalImages && retryAttempt < maxRetries && !service.IsJobCancelled(job.JobID) {
+	for completedCount < job.TotalImages && retryAttempt < maxRetries && !cancel.JobCancelled(ctx, service, job.JobID) {
 		retryAttempt++
 		remaining := job.TotalImages - completedCount
 
@@ -453,7 +461,7 @@ MANDATORY TECHNICAL SPECS:
 		// 부족한 개수만큼 생성
 		for i := 0; i < remaining; i++ {
 			// 취소 체크
-			if service.IsJobCancelled(job.JobID) {
+			if cancel.JobCancelled(ctx, service, job.JobID) {
 				log.Printf("🛑 Job %s cancelled during retry", job.JobID)
 				cancelled = true
 				break
@@ -530,7 +538,7 @@ MANDATORY TECHNICAL SPECS:
 
 	// Phase 5: 최종 완료 처리
 	// 🛑 취소된 Job은 user_cancelled 상태 유지 (completed로 덮어쓰지 않음)
-	if service.IsJobCancelled(job.JobID) || cancelled {
+	if cancel.JobCancelled(ctx, service, job.JobID) || cancelled {
 		log.Printf("🛑 Job %s was cancelled, keeping user_cancelled status", job.JobID)
 		// attach_ids만 업데이트 (이미 생성된 이미지들)
 		if job.ProductionID != nil && len(generatedAttachIds) > 0 {
@@ -842,7 +850,7 @@ MANDATORY TECHNICAL SPECS:
 
 			for i := 0; i < quantity; i++ {
 				// 🛑 취소 체크 - 새 이미지 생성 전에 확인
-				if service.IsJobCancelled(job.JobID) {
+				if cancel.JobCancelled(ctx, service, job.JobID) {
 					log.Printf("🛑 Stage %d: Job %s cancelled, stopping generation", stageIndex, job.JobID)
 					results[stageIndex] = cancel.StageResult{
 						StageIndex: stageIndex,
@@ -858,8 +866,8 @@ MANDATORY TECHNICAL SPECS:
 
 				log.Printf("🎨 Stage %d: Generating image %d/%d...", stageIndex, i+1, quantity)
 
-				// 병렬 처리 레이트 리밋 방지: 3초 딜레이
-				time.Sleep(3 * time.Second)
+				// 병렬 처리 레이트 리밋 방지: 3초 딜레이 (취소되면 바로 깨어남)
+				cancel.Sleep(ctx, 3*time.Second)
 
 				// Gemini API 호출 (카테고리별 이미지 전달, aspect-ratio 포함)
 				generatedBase64, err := service.GenerateImageWithGeminiMultiple(ctx, stageCategories, enhancedPrompt, aspectRatio)
@@ -881,7 +889,7 @@ MANDATORY TECHNICAL SPECS:
 				}
 
 				// 🛑 Gemini 응답 후 취소 체크 - 취소됐으면 저장/차감 안 함
-				if service.IsJobCancelled(job.JobID) {
+				if cancel.JobCancelled(ctx, service, job.JobID) {
 					log.Printf("🛑 Stage %d: Job %s cancelled after generation, discarding image %d", stageIndex, job.JobID, i+1)
 					results[stageIndex] = cancel.StageResult{
 						StageIndex: stageIndex,
@@ -989,7 +997,7 @@ MANDATORY TECHNICAL SPECS:
 	// Step 2: 부족한 Stage만 재시도
 	for stageIdx, stageData := range stages {
 		// 🛑 재시도 전에 취소 체크
-		if service.IsJobCancelled(job.JobID) {
+		if cancel.JobCancelled(ctx, service, job.JobID) {
 			log.Printf("🛑 Job %s cancelled, skipping retry phase", job.JobID)
 			break
 		}
@@ -1072,7 +1080,7 @@ MANDATORY TECHNICAL SPECS:
 		retrySuccess := 0
 		for i := 0; i < missing; i++ {
 			// 🛑 재시도 중 취소 체크
-			if service.IsJobCancelled(job.JobID) {
+			if cancel.JobCancelled(ctx, service, job.JobID) {
 				log.Printf("🛑 Stage %d: Job %s cancelled during retry", stageIdx, job.JobID)
 				service.UpdateJobStatus(ctx, job.JobID, model.StatusUserCancelled)
 				if job.ProductionID != nil {
@@ -1192,7 +1200,7 @@ MANDATORY TECHNICAL SPECS:
 
 	// Phase 4: 최종 완료 처리
 	// 🛑 Cancel된 job은 상태를 덮어쓰지 않음
-	if service.IsJobCancelled(job.JobID) {
+	if cancel.JobCancelled(ctx, service, job.JobID) {
 		log.Printf("🛑 Job %s was cancelled, keeping user_cancelled status", job.JobID)
 		if job.ProductionID != nil && len(allGeneratedAttachIds) > 0 {
 			if err := service.UpdateProductionAttachIds(ctx, *job.ProductionID, allGeneratedAttachIds); err != nil {
@@ -1356,7 +1364,7 @@ func processSimpleGeneral(ctx context.Context, service *Service, job *model.Prod
 
 	for i := 0; i < quantity; i++ {
 		// 🛑 취소 체크
-		if service.IsJobCancelled(job.JobID) {
+		if cancel.JobCancelled(ctx, service, job.JobID) {
 			log.Printf("🛑 Job %s cancelled, stopping generation", job.JobID)
 			service.UpdateJobStatus(ctx, job.JobID, model.StatusUserCancelled)
 			if job.ProductionID != nil {
@@ -14,9 +14,14 @@ type StageResult struct {
 	Success    int
 }
 
+// Checker - 취소 플래그 조회 인터페이스 (Job 컨텍스트가 아닐 때 사용)
+type Checker interface {
+	IsJobCancelled(jobID string) bool
+}
+
 // StatusUpdater - 상태 업데이트 인터페이스
 type StatusUpdater interface {
-	IsJobCancelled(jobID string) bool
+	Checker
 	UpdateJobStatus(ctx context.Context, jobID string, status string) error
 	UpdateProductionPhotoStatus(ctx context.Context, productionID string, status string) error
 	UpdateProductionAttachIds(ctx context.Context, productionID string, attachIds []int) error
@@ -32,7 +37,7 @@ func CheckAndHandleCancelBeforeGeneration(
 	stageGeneratedIds []int,
 	results []StageResult,
 ) bool {
-	if !service.IsJobCancelled(job.JobID) {
+	if !JobCancelled(ctx, service, job.JobID) {
 		return false
 	}
 
@@ -45,6 +50,8 @@ func CheckAndHandleCancelBeforeGeneration(
 		Success:    len(stageGeneratedIds),
 	}
 
+	// Job 컨텍스트는 이미 취소됐으므로 상태 저장은 취소와 무관하게
+	ctx = context.WithoutCancel(ctx)
 	service.UpdateJobStatus(ctx, job.JobID, model.StatusUserCancelled)
 	if job.ProductionID != nil {
 		service.UpdateProductionPhotoStatus(ctx, *job.ProductionID, model.StatusUserCancelled)
@@ -64,7 +71,7 @@ func CheckAndHandleCancelAfterGeneration(
 	stageGeneratedIds []int,
 	results []StageResult,
 ) bool {
-	if !service.IsJobCancelled(job.JobID) {
+	if !JobCancelled(ctx, service, job.JobID) {
 		return false
 	}
 
@@ -77,6 +84,8 @@ func CheckAndHandleCancelAfterGeneration(
 		Success:    len(stageGeneratedIds),
 	}
 
+	// Job 컨텍스트는 이미 취소됐으므로 상태 저장은 취소와 무관하게
+	ctx = context.WithoutCancel(ctx)
 	service.UpdateJobStatus(ctx, job.JobID, model.StatusUserCancelled)
 	if job.ProductionID != nil {
 		service.UpdateProductionPhotoStatus(ctx, *job.ProductionID, model.StatusUserCancelled)
@@ -86,8 +95,8 @@ func CheckAndHandleCancelAfterGeneration(
 }
 
 // CheckCancelForRetryPhase - 재시도 단계 진입 전 취소 체크
-func CheckCancelForRetryPhase(service StatusUpdater, jobID string) bool {
-	if service.IsJobCancelled(jobID) {
+func CheckCancelForRetryPhase(ctx context.Context, service StatusUpdater, jobID string) bool {
+	if JobCancelled(ctx, service, jobID) {
 		log.Printf("🛑 Job %s cancelled, skipping retry phase", jobID)
 		return true
 	}
@@ -95,8 +104,8 @@ func CheckCancelForRetryPhase(service StatusUpdater, jobID string) bool {
 }
 
 // CheckCancelDuringRetry - 재시도 루프 중 취소 체크
-func CheckCancelDuringRetry(service StatusUpdater, jobID string, stageIdx int) bool {
-	if service.IsJobCancelled(jobID) {
+func CheckCancelDuringRetry(ctx context.Context, service StatusUpdater, jobID string, stageIdx int) bool {
+	if JobCancelled(ctx, service, jobID) {
 		log.Printf("🛑 Stage %d: Job %s cancelled during retry", stageIdx, jobID)
 		return true
 	}
@@ -111,7 +120,7 @@ func HandleFinalStatus(
 	job *model.ProductionJob,
 	allGeneratedAttachIds []int,
 ) bool {
-	if !service.IsJobCancelled(job.JobID) {
+	if !JobCancelled(ctx, service, job.JobID) {
 		return false
 	}
 
@@ -119,7 +128,7 @@ func HandleFinalStatus(
 
 	// attach_ids만 업데이트 (이미 생성된 이미지들)
 	if job.ProductionID != nil && len(allGeneratedAttachIds) > 0 {
-		if err := service.UpdateProductionAttachIds(ctx, *job.ProductionID, allGeneratedAttachIds); err != nil {
+		if err := service.UpdateProductionAttachIds(context.WithoutCancel(ctx), *job.ProductionID, allGeneratedAttachIds); err != nil {
 			log.Printf("Failed to update production attach_ids: %v", err)
 		}
 	}
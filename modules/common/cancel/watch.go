@@ -0,0 +1,153 @@
+package cancel
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	redisutil "quel-canvas-server/modules/common/redis"
+)
+
+// ErrJobCancelled - 사용자 취소로 Job 컨텍스트가 끝났을 때의 원인 (context.Cause)
+var ErrJobCancelled = errors.New("job cancelled by user")
+
+// 구독이 끊긴 동안 놓친 알림을 취소 플래그로 보정하는 주기
+const sweepInterval = 10 * time.Second
+
+type jobContextKey struct{}
+
+// watchedJob - 처리 중인 Job 컨텍스트
+type watchedJob struct {
+	cancel context.CancelCauseFunc
+}
+
+var (
+	watchOnce sync.Once
+	mutex     sync.Mutex
+	watchRDB  *redis.Client
+	watched   = make(map[string]*watchedJob)
+)
+
+// Watch - jobs:cancel 구독 시작 (rdb가 nil이면 Job 컨텍스트 없이 취소 플래그 조회만 사용)
+func Watch(rdb *redis.Client) {
+	if rdb == nil {
+		return
+	}
+	watchOnce.Do(func() {
+		mutex.Lock()
+		watchRDB = rdb
+		mutex.Unlock()
+
+		go subscribe(rdb)
+		go sweep(rdb)
+	})
+}
+
+func subscribe(rdb *redis.Client) {
+	pubsub := rdb.Subscribe(context.Background(), redisutil.JobCancelChannel)
+	log.Printf("👂 [Cancel] Subscribed to %s", redisutil.JobCancelChannel)
+
+	for msg := range pubsub.Channel() {
+		cancelJob(msg.Payload)
+	}
+}
+
+// sweep - 처리 중인 Job의 취소 플래그를 한 번에 확인 (재연결 중 놓친 알림 보정)
+func sweep(rdb *redis.Client) {
+	ticker := time.NewTicker(sweepInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		mutex.Lock()
+		jobIDs := make([]string, 0, len(watched))
+		for jobID := range watched {
+			jobIDs = append(jobIDs, jobID)
+		}
+		mutex.Unlock()
+
+		for _, jobID := range jobIDs {
+			if redisutil.IsJobCancelled(rdb, jobID) {
+				cancelJob(jobID)
+			}
+		}
+	}
+}
+
+// cancelJob - 이 인스턴스에서 처리 중인 Job이면 컨텍스트 취소
+func cancelJob(jobID string) {
+	mutex.Lock()
+	job, ok := watched[jobID]
+	mutex.Unlock()
+	if !ok {
+		return
+	}
+
+	log.Printf("🛑 [Cancel] Job %s cancelled - aborting in-flight work", jobID)
+	job.cancel(ErrJobCancelled)
+}
+
+// WithJob - Job 전용 컨텍스트 (취소 알림이 오면 ErrJobCancelled로 취소, 처리가 끝나면 release 호출)
+func WithJob(parent context.Context, jobID string) (context.Context, context.CancelFunc) {
+	ctx, cancelCause := context.WithCancelCause(parent)
+	job := &watchedJob{cancel: cancelCause}
+
+	mutex.Lock()
+	rdb := watchRDB
+	if rdb != nil {
+		watched[jobID] = job
+	}
+	mutex.Unlock()
+
+	release := func() {
+		mutex.Lock()
+		if watched[jobID] == job {
+			delete(watched, jobID)
+		}
+		mutex.Unlock()
+		cancelCause(context.Canceled)
+	}
+
+	// 구독이 없으면 Job 컨텍스트로 표시하지 않음 (JobCancelled가 플래그를 조회)
+	if rdb == nil {
+		return ctx, release
+	}
+
+	// 처리 시작 전에 이미 취소된 Job
+	if redisutil.IsJobCancelled(rdb, jobID) {
+		cancelCause(ErrJobCancelled)
+	}
+	return context.WithValue(ctx, jobContextKey{}, jobID), release
+}
+
+// IsCancelled - 사용자 취소로 끝난 컨텍스트인지 확인
+func IsCancelled(ctx context.Context) bool {
+	return errors.Is(context.Cause(ctx), ErrJobCancelled)
+}
+
+// JobCancelled - Job 취소 여부 확인
+// Job 컨텍스트면 Redis 조회 없이 컨텍스트 상태만 보고, 아니면 (단독 실행 워커 등) 취소 플래그 조회
+func JobCancelled(ctx context.Context, checker Checker, jobID string) bool {
+	if IsCancelled(ctx) {
+		return true
+	}
+	if _, ok := ctx.Value(jobContextKey{}).(string); ok {
+		return false
+	}
+	return checker.IsJobCancelled(jobID)
+}
+
+// Sleep - 취소되면 바로 깨어나는 대기 (끝까지 기다렸으면 true)
+func Sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -22,6 +24,8 @@ type Config struct {
 	SupabaseURL            string
 	SupabaseServiceKey     string
 	SupabaseStorageBaseURL string
+	SupabaseJWTSecret      string // WebSocket 토큰 검증용 (HS256)
+	WSAuthDisabled         bool   // true면 WebSocket/Room API 인증 생략 (로컬 개발용, query 파라미터 신뢰)
 
 	// Gemini API
 	GeminiAPIKey string // 단일 키
@@ -35,10 +39,49 @@ type Config struct {
 	OpenAIAPIKey string
 
 	// Server
-	Port string
+	Port       string
+	InstanceID string // 다중 인스턴스 구분용 (Room Pub/Sub 에코 제거)
 
 	// Credit
 	ImagePerPrice int
+
+	// Collaboration Room 스냅샷 (Visual Editor nodes/edges 영속화)
+	RoomSnapshotTTL      time.Duration // Redis 보관 기간
+	RoomSnapshotDebounce time.Duration // sync-nodes 저장 지연 (연속 동기화 묶음)
+	RoomSnapshotSupabase bool          // Supabase 테이블에도 저장할지 여부
+
+	// WebSocket 허용 Origin 목록 (비어있으면 모두 허용)
+	WSAllowedOrigins []string
+
+	// 재접속 시 재전송할 Room 메시지 보관 개수
+	RoomReplayBuffer int
+
+	// 캔버스 아이템 잠금 유지 시간 (갱신 없으면 자동 해제)
+	ItemLockLease time.Duration
+
+	// 커서/선택 묶음 전송 주기 (presence-batch)
+	PresenceTick time.Duration
+
+	// Room 관리자 API 토큰 (비어있으면 관리자 API 비활성화)
+	AdminAPIToken string
+
+	// Room 녹화 (Redis Stream) 보관 기간/최대 메시지 수
+	RoomRecordingTTL       time.Duration
+	RoomRecordingMaxEvents int64
+
+	// Job 큐 (reliable queue)
+	JobVisibilityTimeout time.Duration // heartbeat 없이 이 시간이 지나면 다른 워커가 재시도
+	JobHeartbeatInterval time.Duration // 처리 중인 Job의 visibility 연장 주기
+	JobRecoveryWindow    time.Duration // 시작 시 processing으로 남은 Job 중 재시도할 최근 범위 (이전 것은 failed 처리)
+	JobDedupTTL          time.Duration // 같은 job_id 재요청을 중복으로 보는 기간
+
+	// 워커 동시 처리 제한 (초과분은 Redis 대기열에서 대기)
+	WorkerMaxConcurrency  int            // 워커 인스턴스 전체 동시 처리 Job 수
+	WorkerPathConcurrency map[string]int // quel_production_path별 동시 처리 Job 수 (없으면 전체 제한만 적용)
+
+	// Job 완료 웹훅
+	WebhookSigningSecret string // 조직 secret이 없을 때 쓰는 서명 키 (둘 다 없으면 전송하지 않고 실패로 기록)
+	WebhookMaxAttempts   int    // 전송 실패 시 최대 시도 횟수 (지수 백오프)
 }
 
 var globalConfig *Config
@@ -66,6 +109,128 @@ func LoadConfig() (*Config, error) {
 		}
 	}
 
+	// Room 스냅샷 설정 파싱
+	snapshotTTLHours := 168 // 기본값 7일
+	if ttlStr := os.Getenv("ROOM_SNAPSHOT_TTL_HOURS"); ttlStr != "" {
+		if parsed, err := strconv.Atoi(ttlStr); err == nil && parsed > 0 {
+			snapshotTTLHours = parsed
+		}
+	}
+	snapshotDebounceMs := 2000
+	if debounceStr := os.Getenv("ROOM_SNAPSHOT_DEBOUNCE_MS"); debounceStr != "" {
+		if parsed, err := strconv.Atoi(debounceStr); err == nil && parsed >= 0 {
+			snapshotDebounceMs = parsed
+		}
+	}
+	snapshotSupabase := false
+	if supaStr := os.Getenv("ROOM_SNAPSHOT_SUPABASE"); supaStr != "" {
+		if parsed, err := strconv.ParseBool(supaStr); err == nil {
+			snapshotSupabase = parsed
+		}
+	}
+
+	// WebSocket 허용 Origin 파싱 (콤마 구분)
+	var wsAllowedOrigins []string
+	for _, origin := range strings.Split(os.Getenv("WS_ALLOWED_ORIGINS"), ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			wsAllowedOrigins = append(wsAllowedOrigins, strings.TrimSuffix(origin, "/"))
+		}
+	}
+
+	// Room 재전송 버퍼 크기 파싱
+	roomReplayBuffer := 1000
+	if bufferStr := os.Getenv("ROOM_REPLAY_BUFFER"); bufferStr != "" {
+		if parsed, err := strconv.Atoi(bufferStr); err == nil && parsed >= 0 {
+			roomReplayBuffer = parsed
+		}
+	}
+
+	// 아이템 잠금 lease 파싱 (초)
+	itemLockLeaseSec := 30
+	if leaseStr := os.Getenv("ITEM_LOCK_LEASE_SECONDS"); leaseStr != "" {
+		if parsed, err := strconv.Atoi(leaseStr); err == nil && parsed > 0 {
+			itemLockLeaseSec = parsed
+		}
+	}
+
+	// presence tick 파싱 (ms)
+	presenceTickMs := 50
+	if tickStr := os.Getenv("PRESENCE_TICK_MS"); tickStr != "" {
+		if parsed, err := strconv.Atoi(tickStr); err == nil && parsed > 0 {
+			presenceTickMs = parsed
+		}
+	}
+
+	// Room 녹화 설정 파싱
+	recordingTTLHours := 168 // 기본값 7일
+	if ttlStr := os.Getenv("ROOM_RECORDING_TTL_HOURS"); ttlStr != "" {
+		if parsed, err := strconv.Atoi(ttlStr); err == nil && parsed > 0 {
+			recordingTTLHours = parsed
+		}
+	}
+	recordingMaxEvents := int64(100000)
+	if maxStr := os.Getenv("ROOM_RECORDING_MAX_EVENTS"); maxStr != "" {
+		if parsed, err := strconv.ParseInt(maxStr, 10, 64); err == nil && parsed > 0 {
+			recordingMaxEvents = parsed
+		}
+	}
+
+	// Job 큐 설정 파싱 (초/시간)
+	jobVisibilitySec := 120
+	if visStr := os.Getenv("JOB_VISIBILITY_TIMEOUT_SECONDS"); visStr != "" {
+		if parsed, err := strconv.Atoi(visStr); err == nil && parsed > 0 {
+			jobVisibilitySec = parsed
+		}
+	}
+	jobHeartbeatSec := 15
+	if hbStr := os.Getenv("JOB_HEARTBEAT_SECONDS"); hbStr != "" {
+		if parsed, err := strconv.Atoi(hbStr); err == nil && parsed > 0 {
+			jobHeartbeatSec = parsed
+		}
+	}
+	jobRecoveryHours := 24
+	if recoveryStr := os.Getenv("JOB_RECOVERY_WINDOW_HOURS"); recoveryStr != "" {
+		if parsed, err := strconv.Atoi(recoveryStr); err == nil && parsed > 0 {
+			jobRecoveryHours = parsed
+		}
+	}
+
+	jobDedupHours := 24
+	if dedupStr := os.Getenv("JOB_DEDUP_TTL_HOURS"); dedupStr != "" {
+		if parsed, err := strconv.Atoi(dedupStr); err == nil && parsed > 0 {
+			jobDedupHours = parsed
+		}
+	}
+
+	// 웹훅 재시도 횟수 파싱
+	webhookMaxAttempts := 8
+	if attemptsStr := os.Getenv("WEBHOOK_MAX_ATTEMPTS"); attemptsStr != "" {
+		if parsed, err := strconv.Atoi(attemptsStr); err == nil && parsed > 0 {
+			webhookMaxAttempts = parsed
+		}
+	}
+
+	// 워커 동시 처리 제한 파싱 (WORKER_PATH_CONCURRENCY 예: "fashion=4,cinema=2,multiview=1")
+	workerMaxConcurrency := 8
+	if maxStr := os.Getenv("WORKER_MAX_CONCURRENCY"); maxStr != "" {
+		if parsed, err := strconv.Atoi(maxStr); err == nil && parsed > 0 {
+			workerMaxConcurrency = parsed
+		}
+	}
+	workerPathConcurrency := make(map[string]int)
+	for _, entry := range strings.Split(os.Getenv("WORKER_PATH_CONCURRENCY"), ",") {
+		path, limitStr, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok {
+			continue
+		}
+		limit, err := strconv.Atoi(strings.TrimSpace(limitStr))
+		if err != nil || limit <= 0 {
+			log.Printf("⚠️ Invalid WORKER_PATH_CONCURRENCY entry: %s", entry)
+			continue
+		}
+		workerPathConcurrency[strings.ToLower(strings.TrimSpace(path))] = limit
+	}
+
 	globalConfig = &Config{
 		// Redis
 		RedisHost:     getEnv("REDIS_HOST", "localhost"),
@@ -78,6 +243,11 @@ func LoadConfig() (*Config, error) {
 		SupabaseURL:            getEnv("SUPABASE_URL", ""),
 		SupabaseServiceKey:     getEnv("SUPABASE_SERVICE_KEY", ""),
 		SupabaseStorageBaseURL: getEnv("SUPABASE_STORAGE_BASE_URL", ""),
+		SupabaseJWTSecret:      getEnv("SUPABASE_JWT_SECRET", ""),
+		WSAuthDisabled:         getEnv("WS_AUTH_DISABLED", "false") == "true",
+
+		// Admin
+		AdminAPIToken: getEnv("ADMIN_API_TOKEN", ""),
 
 		// Gemini API
 		GeminiAPIKey: getEnv("GEMINI_API_KEY", ""),
@@ -91,10 +261,40 @@ func LoadConfig() (*Config, error) {
 		OpenAIAPIKey: getEnv("OPENAI_API_KEY", ""),
 
 		// Server
-		Port: getEnv("PORT", "8080"),
+		Port:       getEnv("PORT", "8080"),
+		InstanceID: getEnv("INSTANCE_ID", getEnv("RENDER_INSTANCE_ID", "")),
 
 		// Credit
 		ImagePerPrice: imagePerPrice,
+
+		// Collaboration Room 스냅샷
+		RoomSnapshotTTL:      time.Duration(snapshotTTLHours) * time.Hour,
+		RoomSnapshotDebounce: time.Duration(snapshotDebounceMs) * time.Millisecond,
+		RoomSnapshotSupabase: snapshotSupabase,
+
+		// Collaboration Room 녹화
+		RoomRecordingTTL:       time.Duration(recordingTTLHours) * time.Hour,
+		RoomRecordingMaxEvents: recordingMaxEvents,
+
+		// Job 큐
+		JobVisibilityTimeout: time.Duration(jobVisibilitySec) * time.Second,
+		JobHeartbeatInterval: time.Duration(jobHeartbeatSec) * time.Second,
+		JobRecoveryWindow:    time.Duration(jobRecoveryHours) * time.Hour,
+		JobDedupTTL:          time.Duration(jobDedupHours) * time.Hour,
+
+		// 워커 동시 처리 제한
+		WorkerMaxConcurrency:  workerMaxConcurrency,
+		WorkerPathConcurrency: workerPathConcurrency,
+
+		// Job 완료 웹훅
+		WebhookSigningSecret: getEnv("WEBHOOK_SIGNING_SECRET", ""),
+		WebhookMaxAttempts:   webhookMaxAttempts,
+
+		// WebSocket
+		WSAllowedOrigins: wsAllowedOrigins,
+		RoomReplayBuffer: roomReplayBuffer,
+		ItemLockLease:    time.Duration(itemLockLeaseSec) * time.Second,
+		PresenceTick:     time.Duration(presenceTickMs) * time.Millisecond,
 	}
 
 	// 필수 환경변수 검증
@@ -104,11 +304,24 @@ func LoadConfig() (*Config, error) {
 
 	log.Println("✅ Configuration loaded successfully")
 	log.Printf("   Redis: %s:%s (TLS: %v)", globalConfig.RedisHost, globalConfig.RedisPort, globalConfig.RedisUseTLS)
-	log.Printf("   Supabase: %s", globalConfig.SupabaseURL)
+	log.Printf("   Supabase: %s (JWT secret: %v, WebSocket auth disabled: %v)",
+		globalConfig.SupabaseURL, globalConfig.SupabaseJWTSecret != "", globalConfig.WSAuthDisabled)
 	log.Printf("   Gemini: %s (API Key: %v)", globalConfig.GeminiModel, globalConfig.GeminiAPIKey != "")
 	log.Printf("   Runware: %s (key: %v)", globalConfig.RunwareAPIURL, globalConfig.RunwareAPIKey != "")
 	log.Printf("   OpenAI: %v", globalConfig.OpenAIAPIKey != "")
 	log.Printf("   Credit: %d per image", globalConfig.ImagePerPrice)
+	log.Printf("   WebSocket origins: %v (replay buffer: %d, item lock lease: %v, presence tick: %v)",
+		globalConfig.WSAllowedOrigins, globalConfig.RoomReplayBuffer, globalConfig.ItemLockLease, globalConfig.PresenceTick)
+	log.Printf("   Room snapshot: TTL %v, debounce %v (Supabase: %v)",
+		globalConfig.RoomSnapshotTTL, globalConfig.RoomSnapshotDebounce, globalConfig.RoomSnapshotSupabase)
+	log.Printf("   Room recording: TTL %v, max events %d",
+		globalConfig.RoomRecordingTTL, globalConfig.RoomRecordingMaxEvents)
+	log.Printf("   Job queue: visibility timeout %v, heartbeat %v, recovery window %v, dedup TTL %v",
+		globalConfig.JobVisibilityTimeout, globalConfig.JobHeartbeatInterval, globalConfig.JobRecoveryWindow, globalConfig.JobDedupTTL)
+	log.Printf("   Worker concurrency: %d (per path: %v)",
+		globalConfig.WorkerMaxConcurrency, globalConfig.WorkerPathConcurrency)
+	log.Printf("   Webhooks: max attempts %d (default secret: %v)",
+		globalConfig.WebhookMaxAttempts, globalConfig.WebhookSigningSecret != "")
 
 	return globalConfig, nil
 }
@@ -135,6 +348,11 @@ func (c *Config) validate() error {
 	if c.GeminiAPIKey == "" {
 		return fmt.Errorf("GEMINI_API_KEY is required")
 	}
+	// 하트비트가 visibility보다 늦으면 처리 중 Job이 만료되어 다른 워커가 중복 처리
+	if c.JobHeartbeatInterval >= c.JobVisibilityTimeout {
+		return fmt.Errorf("JOB_HEARTBEAT_SECONDS (%v) must be shorter than JOB_VISIBILITY_TIMEOUT_SECONDS (%v)",
+			c.JobHeartbeatInterval, c.JobVisibilityTimeout)
+	}
 	return nil
 }
 
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"strconv"
 
 	"github.com/supabase-community/supabase-go"
 	"quel-canvas-server/modules/common/config"
@@ -100,3 +101,39 @@ func (c *Client) DeductCredits(ctx context.Context, userID string, productionID
 	log.Printf("✅ Credits deducted successfully: %d credits from user %s", totalCredits, userID)
 	return nil
 }
+
+// ChargedForAttaches - 생성 이미지들에 실제로 차감된 크레딧 합계 (quel_credits DEDUCT 기록 기준)
+func (c *Client) ChargedForAttaches(ctx context.Context, attachIds []int) (int, error) {
+	if len(attachIds) == 0 {
+		return 0, nil
+	}
+
+	ids := make([]string, 0, len(attachIds))
+	for _, attachID := range attachIds {
+		ids = append(ids, strconv.Itoa(attachID))
+	}
+
+	var transactions []struct {
+		Amount int `json:"amount"`
+	}
+
+	data, _, err := c.supabase.From("quel_credits").
+		Select("amount", "", false).
+		Eq("transaction_type", "DEDUCT").
+		In("attach_idx", ids).
+		Execute()
+
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch credit transactions: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &transactions); err != nil {
+		return 0, fmt.Errorf("failed to parse credit transactions: %w", err)
+	}
+
+	charged := 0
+	for _, transaction := range transactions {
+		charged -= transaction.Amount
+	}
+	return charged, nil
+}
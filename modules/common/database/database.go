@@ -3,6 +3,7 @@ package database
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -10,9 +11,13 @@ import (
 
 	"github.com/supabase-community/supabase-go"
 	"quel-canvas-server/modules/common/config"
+	"quel-canvas-server/modules/common/jobevents"
 	"quel-canvas-server/modules/common/model"
 )
 
+// ErrJobNotFound - quel_production_jobs에 해당 job_id가 없음 (조회 실패와 구분)
+var ErrJobNotFound = errors.New("job not found")
+
 type Client struct {
 	supabase *supabase.Client
 }
@@ -54,7 +59,7 @@ func (c *Client) FetchJobFromSupabase(jobID string) (*model.ProductionJob, error
 	}
 
 	if len(jobs) == 0 {
-		return nil, fmt.Errorf("job not found: %s", jobID)
+		return nil, fmt.Errorf("%w: %s", ErrJobNotFound, jobID)
 	}
 
 	job := &jobs[0]
@@ -64,6 +69,26 @@ func (c *Client) FetchJobFromSupabase(jobID string) (*model.ProductionJob, error
 	return job, nil
 }
 
+// FetchJobsByStatus - 특정 상태의 Job 목록 조회 (워커 재시작 시 복구용)
+func (c *Client) FetchJobsByStatus(status string) ([]model.ProductionJob, error) {
+	var jobs []model.ProductionJob
+
+	data, _, err := c.supabase.From("quel_production_jobs").
+		Select("*", "exact", false).
+		Eq("job_status", status).
+		Execute()
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to query Supabase: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &jobs); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	return jobs, nil
+}
+
 // UpdateJobStatus - Job 상태 업데이트
 func (c *Client) UpdateJobStatus(ctx context.Context, jobID string, status string) error {
 	log.Printf("📝 Updating job %s status to: %s", jobID, status)
@@ -89,6 +114,7 @@ func (c *Client) UpdateJobStatus(ctx context.Context, jobID string, status strin
 	}
 
 	log.Printf("✅ Job %s status updated to: %s", jobID, status)
+	jobevents.JobStatus(jobID, status)
 	return nil
 }
 
@@ -201,6 +227,7 @@ func (c *Client) CreateAttachRecord(ctx context.Context, filePath string, fileSi
 	attachID := int(attaches[0].AttachID)
 	log.Printf("✅ Attach record created: ID=%d", attachID)
 
+	jobevents.AttachCreated(attachID, filePath)
 	return attachID, nil
 }
 
@@ -224,6 +251,7 @@ func (c *Client) UpdateJobProgress(ctx context.Context, jobID string, completedI
 	}
 
 	log.Printf("✅ Job progress updated: %d images completed", completedImages)
+	jobevents.ImageDone(jobID, completedImages, generatedAttachIds)
 	return nil
 }
 
@@ -248,6 +276,27 @@ func (c *Client) UpdateJobFailed(ctx context.Context, jobID string, errorMsg str
 	}
 
 	log.Printf("✅ Job %s marked as failed", jobID)
+	jobevents.JobStatus(jobID, model.StatusFailed)
+	return nil
+}
+
+// UpdateJobRetryCount - 워커가 Job을 시작할 때마다 시도 횟수 기록
+func (c *Client) UpdateJobRetryCount(ctx context.Context, jobID string, retryCount int) error {
+	updateData := map[string]interface{}{
+		"retry_count": retryCount,
+		"updated_at":  "now()",
+	}
+
+	_, _, err := c.supabase.From("quel_production_jobs").
+		Update(updateData, "", "").
+		Eq("job_id", jobID).
+		Execute()
+
+	if err != nil {
+		return fmt.Errorf("failed to update job retry count: %w", err)
+	}
+
+	log.Printf("🔁 Job %s attempt #%d", jobID, retryCount)
 	return nil
 }
 
@@ -273,6 +322,7 @@ func (c *Client) UpdateJobCompleted(ctx context.Context, jobID string, generated
 	}
 
 	log.Printf("✅ Job %s marked as completed", jobID)
+	jobevents.JobStatus(jobID, model.StatusCompleted)
 	return nil
 }
 
@@ -373,5 +423,6 @@ func (c *Client) UpdateProductionAttachIds(ctx context.Context, productionID str
 	}
 
 	log.Printf("✅ Production attach_ids updated: %v", mergedIds)
+	jobevents.ProductionAttached(productionID, newAttachIds)
 	return nil
 }
@@ -0,0 +1,64 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"quel-canvas-server/modules/common/model"
+)
+
+// FetchOrgWebhook - 조직 웹훅 설정 조회 (없으면 nil)
+func (c *Client) FetchOrgWebhook(orgID string) (*model.OrgWebhook, error) {
+	var webhooks []model.OrgWebhook
+
+	data, _, err := c.supabase.From("quel_org_webhooks").
+		Select("*", "", false).
+		Eq("org_id", orgID).
+		Execute()
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to query org webhook: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &webhooks); err != nil {
+		return nil, fmt.Errorf("failed to parse org webhook: %w", err)
+	}
+
+	if len(webhooks) == 0 {
+		return nil, nil
+	}
+	return &webhooks[0], nil
+}
+
+// UpsertOrgWebhook - 조직 웹훅 설정 저장 (org_id 기준)
+func (c *Client) UpsertOrgWebhook(webhook *model.OrgWebhook) error {
+	webhook.UpdatedAt = time.Now()
+
+	_, _, err := c.supabase.From("quel_org_webhooks").
+		Upsert(webhook, "org_id", "minimal", "").
+		Execute()
+
+	if err != nil {
+		return fmt.Errorf("failed to save org webhook: %w", err)
+	}
+
+	log.Printf("✅ Org %s webhook saved: %s (enabled: %v)", webhook.OrgID, webhook.URL, webhook.Enabled)
+	return nil
+}
+
+// DeleteOrgWebhook - 조직 웹훅 설정 삭제
+func (c *Client) DeleteOrgWebhook(orgID string) error {
+	_, _, err := c.supabase.From("quel_org_webhooks").
+		Delete("", "").
+		Eq("org_id", orgID).
+		Execute()
+
+	if err != nil {
+		return fmt.Errorf("failed to delete org webhook: %w", err)
+	}
+
+	log.Printf("🗑️ Org %s webhook deleted", orgID)
+	return nil
+}
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"google.golang.org/genai"
+	"quel-canvas-server/modules/common/cancel"
 )
 
 // GenerateContentWithRetry - 단일 API 키로 최대 10번 재시도하는 헬퍼 함수
@@ -47,7 +48,9 @@ func GenerateContentWithRetry(
 		if err != nil {
 			log.Printf("⚠️  [Gemini Retry] Failed to create client (attempt %d): %v", attempt, err)
 			lastErr = err
-			time.Sleep(3 * time.Second)
+			if !cancel.Sleep(ctx, 3*time.Second) {
+				return nil, context.Cause(ctx)
+			}
 			continue
 		}
 
@@ -72,7 +75,9 @@ func GenerateContentWithRetry(
 
 		if attempt < maxRetries {
 			log.Printf("   ⏳ Waiting 3 seconds before retry...")
-			time.Sleep(3 * time.Second)
+			if !cancel.Sleep(ctx, 3*time.Second) {
+				return nil, context.Cause(ctx)
+			}
 		}
 	}
 
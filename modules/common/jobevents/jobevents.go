@@ -0,0 +1,335 @@
+package jobevents
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"quel-canvas-server/modules/common/config"
+	"quel-canvas-server/modules/common/model"
+)
+
+// Channel - Job 진행 이벤트 Redis Pub/Sub 채널 (협업 서버가 구독)
+const Channel = "jobs:progress"
+
+// StreamPrefix - Job별 이벤트 기록 Redis Stream (jobs:events:{jobId}, SSE 재접속 시 Last-Event-ID 이후부터 재전송)
+const StreamPrefix = "jobs:events:"
+
+// 이벤트 종류
+const (
+	EventStarted   = "started"
+	EventImageDone = "image_done"
+	EventAttached  = "attachments_saved" // UpdateProductionAttachIds 이후 Production에 연결된 attach ID
+	EventFailed    = "failed"
+	EventCancelled = "cancelled"
+	EventCompleted = "completed"
+	EventSummary   = "summary" // completed/failed/cancelled 직후 마지막 이벤트
+)
+
+const (
+	publishTimeout = 2 * time.Second
+	streamMaxLen   = 1000
+	streamTTL      = 24 * time.Hour
+	attachInfoTTL  = 30 * time.Minute // image_done으로 보고되지 않은 attach 정보 보관 시간
+)
+
+// Event - jobs:progress 채널로 발행되는 Job 진행 이벤트
+type Event struct {
+	JobID           string                 `json:"jobId"`
+	Event           string                 `json:"event"`
+	Status          string                 `json:"status,omitempty"`
+	OrgID           string                 `json:"orgId,omitempty"`
+	WorkspaceID     string                 `json:"workspaceId,omitempty"`
+	UserID          string                 `json:"userId,omitempty"`
+	ProductionID    string                 `json:"productionId,omitempty"`
+	TotalImages     int                    `json:"totalImages"`
+	CompletedImages int                    `json:"completedImages"`
+	AttachID        int                    `json:"attachId,omitempty"`  // image_done: 방금 생성된 이미지
+	URL             string                 `json:"url,omitempty"`       // image_done: 공개 URL
+	Detail          map[string]interface{} `json:"detail,omitempty"`    // image_done: combination/angle/shot 등
+	AttachIDs       []int                  `json:"attachIds,omitempty"` // attachments_saved, summary
+	URLs            []string               `json:"urls,omitempty"`      // summary: 생성된 이미지 공개 URL
+	Timestamp       time.Time              `json:"timestamp"`
+}
+
+// route - Job을 어느 Room/사용자에게 보낼지 (Track 시 Job 데이터에서 추출)
+type route struct {
+	orgID        string
+	workspaceID  string
+	userID       string
+	productionID string
+	totalImages  int
+	completed    int      // 마지막으로 발행한 완료 수 (같은 진행률 중복 발행 방지)
+	attachIDs    []int    // summary용 생성 attach ID
+	urls         []string // summary용 공개 URL
+}
+
+// attachInfo - CreateAttachRecord/DescribeImage로 등록된 이미지 정보 (image_done 발행 시 사용)
+type attachInfo struct {
+	url       string
+	detail    map[string]interface{}
+	createdAt time.Time
+}
+
+var (
+	rdb            *redis.Client
+	storageBaseURL string
+	mutex          sync.Mutex
+	routes         = make(map[string]*route)
+	attachments    = make(map[int]*attachInfo)
+
+	finishedHandlers []func(Event) // summary 발행 후 호출 (웹훅 등)
+)
+
+// Init - 발행용 Redis 클라이언트 설정 (nil이면 발행하지 않음)
+func Init(client *redis.Client) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	rdb = client
+	storageBaseURL = config.GetConfig().SupabaseStorageBaseURL
+}
+
+// OnFinished - Job이 completed/failed/user_cancelled로 끝났을 때 summary 이벤트와 함께 호출할 함수 등록
+func OnFinished(handler func(Event)) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	finishedHandlers = append(finishedHandlers, handler)
+}
+
+// StreamKey - Job 이벤트 기록 Stream 키
+func StreamKey(jobID string) string {
+	return StreamPrefix + jobID
+}
+
+// Track - 처리 시작 전 Job의 org/workspace/요청자 등록
+func Track(job *model.ProductionJob) {
+	if job == nil {
+		return
+	}
+
+	r := newRoute(job)
+	mutex.Lock()
+	routes[job.JobID] = r
+	mutex.Unlock()
+}
+
+// TrackUntracked - 이 프로세스가 처리 중이지 않은 Job을 DB 행 기준으로 등록 (복구처럼 처리 없이 종료 상태로 바꾸는 경우)
+// 이미 등록된 Job이면 기존 경로를 유지하고, 반환된 함수는 이번에 등록한 경우에만 해제
+func TrackUntracked(job *model.ProductionJob) func() {
+	if job == nil {
+		return func() {}
+	}
+
+	r := newRoute(job)
+	for _, id := range job.GeneratedAttachIDs {
+		if floatID, ok := id.(float64); ok {
+			r.attachIDs = append(r.attachIDs, int(floatID))
+		}
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	if _, exists := routes[job.JobID]; exists {
+		return func() {}
+	}
+	routes[job.JobID] = r
+	return func() { Forget(job.JobID) }
+}
+
+// newRoute - Job 데이터에서 이벤트 전달 경로 추출
+func newRoute(job *model.ProductionJob) *route {
+	r := &route{totalImages: job.TotalImages, completed: job.CompletedImages}
+	if job.OrgID != nil {
+		r.orgID = *job.OrgID
+	}
+	if job.ProductionID != nil {
+		r.productionID = *job.ProductionID
+	}
+	if job.QuelMemberID != nil {
+		r.userID = *job.QuelMemberID
+	}
+	r.workspaceID = inputString(job.JobInputData, "workspaceId", "workspace_id")
+	if r.userID == "" {
+		r.userID = inputString(job.JobInputData, "userId", "user_id")
+	}
+	return r
+}
+
+// Forget - 처리 종료 후 등록 해제
+func Forget(jobID string) {
+	mutex.Lock()
+	delete(routes, jobID)
+	mutex.Unlock()
+}
+
+// AttachCreated - CreateAttachRecord 이후 호출 (image_done 이벤트에 공개 URL 포함)
+func AttachCreated(attachID int, filePath string) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	if rdb == nil {
+		return
+	}
+	attachInfoFor(attachID).url = storageBaseURL + filePath
+}
+
+// DescribeImage - 조합 루프에서 UpdateJobProgress 전에 호출 (combination/angle/shot 등)
+func DescribeImage(attachID int, detail map[string]interface{}) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	if rdb == nil {
+		return
+	}
+	attachInfoFor(attachID).detail = detail
+}
+
+// attachInfoFor - attach 정보 조회/생성 (mutex 보유 상태에서 호출, 오래된 항목 정리)
+func attachInfoFor(attachID int) *attachInfo {
+	if info, ok := attachments[attachID]; ok {
+		return info
+	}
+	now := time.Now()
+	for id, info := range attachments {
+		if now.Sub(info.createdAt) > attachInfoTTL {
+			delete(attachments, id)
+		}
+	}
+	info := &attachInfo{createdAt: now}
+	attachments[attachID] = info
+	return info
+}
+
+// JobStatus - UpdateJobStatus 이후 호출 (processing/completed/failed/user_cancelled만 발행)
+func JobStatus(jobID string, status string) {
+	var event string
+	switch status {
+	case model.StatusProcessing:
+		event = EventStarted
+	case model.StatusCompleted:
+		event = EventCompleted
+	case model.StatusFailed, model.StatusError:
+		event = EventFailed
+	case model.StatusUserCancelled:
+		event = EventCancelled
+	default:
+		return
+	}
+	publish(jobID, Event{Event: event, Status: status}, -1)
+
+	// 끝난 Job은 결과 요약을 마지막으로 발행
+	if event != EventStarted {
+		publish(jobID, Event{Event: EventSummary, Status: status}, -1)
+	}
+}
+
+// ImageDone - UpdateJobProgress 이후 호출 (완료 수가 늘었을 때만 마지막 attach ID와 함께 발행)
+func ImageDone(jobID string, completedImages int, attachIDs []int) {
+	event := Event{Event: EventImageDone, CompletedImages: completedImages}
+	if len(attachIDs) > 0 {
+		event.AttachID = attachIDs[len(attachIDs)-1]
+	}
+	publish(jobID, event, completedImages)
+}
+
+// ProductionAttached - UpdateProductionAttachIds 이후 호출 (해당 Production을 처리 중인 Job으로 발행)
+func ProductionAttached(productionID string, attachIDs []int) {
+	mutex.Lock()
+	var jobIDs []string
+	for jobID, r := range routes {
+		if r.productionID == productionID {
+			jobIDs = append(jobIDs, jobID)
+		}
+	}
+	mutex.Unlock()
+
+	for _, jobID := range jobIDs {
+		publish(jobID, Event{Event: EventAttached, AttachIDs: attachIDs}, -1)
+	}
+}
+
+// publish - 등록된 경로 정보를 채워 발행 (completed >= 0이면 진행률이 늘었을 때만)
+func publish(jobID string, event Event, completed int) {
+	mutex.Lock()
+	client := rdb
+	r := routes[jobID]
+	if client == nil || r == nil {
+		mutex.Unlock()
+		return
+	}
+	if completed >= 0 {
+		if completed <= r.completed {
+			mutex.Unlock()
+			return
+		}
+		r.completed = completed
+	}
+	event.JobID = jobID
+	event.OrgID = r.orgID
+	event.WorkspaceID = r.workspaceID
+	event.UserID = r.userID
+	event.ProductionID = r.productionID
+	event.TotalImages = r.totalImages
+	event.CompletedImages = r.completed
+	switch event.Event {
+	case EventImageDone:
+		if info, ok := attachments[event.AttachID]; ok && event.AttachID != 0 {
+			event.URL = info.url
+			event.Detail = info.detail
+			delete(attachments, event.AttachID)
+		}
+		if event.AttachID != 0 {
+			r.attachIDs = append(r.attachIDs, event.AttachID)
+			if event.URL != "" {
+				r.urls = append(r.urls, event.URL)
+			}
+		}
+	case EventSummary:
+		event.AttachIDs = append([]int(nil), r.attachIDs...)
+		event.URLs = append([]string(nil), r.urls...)
+	}
+	var handlers []func(Event)
+	if event.Event == EventSummary {
+		handlers = append(handlers, finishedHandlers...)
+	}
+	mutex.Unlock()
+
+	event.Timestamp = time.Now()
+	for _, handler := range handlers {
+		go handler(event)
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("❌ [JobEvents] Failed to marshal %s event for job %s: %v", event.Event, jobID, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+	defer cancel()
+
+	// Room 전달용 Pub/Sub + SSE 재전송용 Job별 Stream
+	pipe := client.Pipeline()
+	pipe.Publish(ctx, Channel, payload)
+	pipe.XAdd(ctx, &redis.XAddArgs{
+		Stream: StreamKey(jobID),
+		MaxLen: streamMaxLen,
+		Approx: true,
+		Values: map[string]interface{}{"event": event.Event, "data": payload},
+	})
+	pipe.Expire(ctx, StreamKey(jobID), streamTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Printf("❌ [JobEvents] Failed to publish %s event for job %s: %v", event.Event, jobID, err)
+	}
+}
+
+func inputString(data map[string]interface{}, keys ...string) string {
+	for _, key := range keys {
+		if value, ok := data[key].(string); ok && value != "" {
+			return value
+		}
+	}
+	return ""
+}
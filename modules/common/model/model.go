@@ -72,3 +72,12 @@ const (
 	StatusUserCancelled = "user_cancelled"
 	StatusError         = "error"
 )
+
+// OrgWebhook - quel_org_webhooks 테이블 구조 (조직별 Job 완료 콜백)
+type OrgWebhook struct {
+	OrgID     string    `json:"org_id"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"secret"` // HMAC-SHA256 서명 키
+	Enabled   bool      `json:"enabled"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
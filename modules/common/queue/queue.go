@@ -0,0 +1,104 @@
+package queue
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"quel-canvas-server/modules/common/model"
+)
+
+// 우선순위 레인 (앞에 있을수록 우선)
+// paid: 조직 크레딧으로 실행하는 Job, personal: 개인 멤버 Job, guest: 랜딩/비로그인 Job
+const (
+	LanePaid     = "paid"
+	LanePersonal = "personal"
+	LaneGuest    = "guest"
+)
+
+// Lanes - 우선순위 순서의 레인 목록
+var Lanes = []string{LanePaid, LanePersonal, LaneGuest}
+
+// 레인 Redis 키
+// jobs:lane:{lane}:owners - 대기 Job이 있는 소유자(조직/사용자) 라운드로빈 목록
+// jobs:lane:{lane}:owner:{owner} - 소유자별 대기 Job (LPUSH로 추가, 오른쪽에서 꺼냄)
+// jobs:seen:{jobId} - 이미 대기열에 넣은 job_id (TTL 동안 재요청은 중복 처리)
+const (
+	LaneKeyPrefix = "jobs:lane:"
+	MetricsKey    = "jobs:metrics" // {lane}:enqueued / {lane}:dequeued 누적 카운터
+	SeenPrefix    = "jobs:seen:"
+)
+
+// OwnersKey - 레인의 소유자 라운드로빈 목록 키
+func OwnersKey(lane string) string {
+	return LaneKeyPrefix + lane + ":owners"
+}
+
+// OwnerKey - 소유자별 대기 목록 키
+func OwnerKey(lane string, owner string) string {
+	return LaneKeyPrefix + lane + ":owner:" + owner
+}
+
+// SeenKey - job_id 중복 기록 키
+func SeenKey(jobID string) string {
+	return SeenPrefix + jobID
+}
+
+// JobLane - Job의 레인과 공정 분배 단위 (조직 Job은 조직끼리, 개인 Job은 사용자끼리 번갈아 처리)
+func JobLane(job *model.ProductionJob) (lane string, owner string) {
+	if job.QuelProductionPath == "landing" {
+		if job.QuelMemberID != nil && *job.QuelMemberID != "" {
+			return LaneGuest, "member:" + *job.QuelMemberID
+		}
+		return LaneGuest, "anonymous"
+	}
+	if job.OrgID != nil && *job.OrgID != "" {
+		return LanePaid, "org:" + *job.OrgID
+	}
+	if job.QuelMemberID != nil && *job.QuelMemberID != "" {
+		return LanePersonal, "member:" + *job.QuelMemberID
+	}
+	return LaneGuest, "anonymous"
+}
+
+// enqueueScript - 소유자 목록에 추가하고, 처음 대기하는 소유자면 라운드로빈 목록 끝에 등록
+var enqueueScript = redis.NewScript(`
+local n = redis.call('LPUSH', KEYS[1], ARGV[1])
+if n == 1 then
+	redis.call('RPUSH', KEYS[2], ARGV[2])
+end
+redis.call('HINCRBY', KEYS[3], ARGV[3] .. ':enqueued', 1)
+return n
+`)
+
+// markSeenScript - 중복 기록이 없으면 기록, ARGV[3](실패 marker)이 있으면 같은 실패로 이미 다시 넣은 경우만 중복
+// 실패 재시도는 기록을 marker로 덮어써서 삭제 후 SETNX 사이에 다른 요청이 끼어들지 않음
+var markSeenScript = redis.NewScript(`
+local seen = redis.call('GET', KEYS[1])
+if seen and (ARGV[3] == '' or seen == ARGV[3]) then
+	return 0
+end
+local value = ARGV[1]
+if ARGV[3] ~= '' then
+	value = ARGV[3]
+end
+redis.call('SET', KEYS[1], value, 'EX', ARGV[2])
+return 1
+`)
+
+// Enqueue - Job을 레인/소유자 대기열에 추가 (반환: 레인, 소유자 대기 Job 수)
+func Enqueue(ctx context.Context, rdb *redis.Client, job *model.ProductionJob) (string, int64, error) {
+	lane, owner := JobLane(job)
+	n, err := enqueueScript.Run(ctx, rdb,
+		[]string{OwnerKey(lane, owner), OwnersKey(lane), MetricsKey},
+		job.JobID, owner, lane).Int64()
+	return lane, n, err
+}
+
+// MarkSeen - 처음 넣는 job_id면 ttl 동안 기록하고 true (이미 넣었으면 false)
+// failureMarker가 있으면 (실패 Job 재시도) 같은 실패로 이미 다시 넣은 경우만 중복
+func MarkSeen(ctx context.Context, rdb *redis.Client, jobID string, ttl time.Duration, failureMarker string) (bool, error) {
+	return markSeenScript.Run(ctx, rdb, []string{SeenKey(jobID)},
+		time.Now().Unix(), int64(ttl.Seconds()), failureMarker).Bool()
+}
@@ -48,6 +48,9 @@ func Connect(cfg *config.Config) *redis.Client {
 	return rdb
 }
 
+// JobCancelChannel - Job 취소 알림 채널 (payload: job ID, 처리 중인 워커가 Job 컨텍스트를 취소)
+const JobCancelChannel = "jobs:cancel"
+
 // SetJobCancelled - Job 취소 플래그 설정
 func SetJobCancelled(rdb *redis.Client, jobID string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -58,6 +61,14 @@ func SetJobCancelled(rdb *redis.Client, jobID string) error {
 	return rdb.Set(ctx, key, "true", 1*time.Hour).Err()
 }
 
+// PublishJobCancelled - Job 취소 알림 발행 (플래그 설정 후 호출)
+func PublishJobCancelled(rdb *redis.Client, jobID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	return rdb.Publish(ctx, JobCancelChannel, jobID).Err()
+}
+
 // IsJobCancelled - Job 취소 여부 확인
 func IsJobCancelled(rdb *redis.Client, jobID string) bool {
 	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
@@ -16,6 +16,7 @@ import (
 	"quel-canvas-server/modules/common/cancel"
 	"quel-canvas-server/modules/common/config"
 	"quel-canvas-server/modules/common/fallback"
+	"quel-canvas-server/modules/common/jobevents"
 	"quel-canvas-server/modules/common/model"
 )
 
@@ -356,7 +357,7 @@ func processSingleBatch(ctx context.Context, service *Service, job *model.Produc
 			// 해당 조합의 quantity만큼 생성
 			for i := 0; i < quantity; i++ {
 				// 🛑 취소 체크 - 새 이미지 생성 전에 확인
-				if service.IsJobCancelled(job.JobID) {
+				if cancel.JobCancelled(ctx, service, job.JobID) {
 					log.Printf("🛑 Combination %d: Job %s cancelled, stopping generation", idx+1, job.JobID)
 					// 상태 업데이트
 					service.UpdateJobStatus(ctx, job.JobID, model.StatusUserCancelled)
@@ -389,7 +390,7 @@ func processSingleBatch(ctx context.Context, service *Service, job *model.Produc
 				}
 
 				// 🛑 Gemini 응답 후 취소 체크 - 취소됐으면 저장/차감 안 함
-				if service.IsJobCancelled(job.JobID) {
+				if cancel.JobCancelled(ctx, service, job.JobID) {
 					log.Printf("🛑 Combination %d: Job %s cancelled after generation, discarding image %d", idx+1, job.JobID, i+1)
 					service.UpdateJobStatus(ctx, job.JobID, model.StatusUserCancelled)
 					if job.ProductionID != nil {
@@ -440,6 +441,13 @@ func processSingleBatch(ctx context.Context, service *Service, job *model.Produc
 				log.Printf("✅ Combination %d: Image %d/%d completed for [%s + %s]: AttachID=%d",
 					idx+1, i+1, quantity, angle, shot, attachID)
 
+				// SSE/Room 이벤트에 조합 정보 포함
+				jobevents.DescribeImage(attachID, map[string]interface{}{
+					"combination": idx + 1,
+					"angle":       angle,
+					"shot":        shot,
+				})
+
 				// 진행 상황 업데이트
 				if err := service.UpdateJobProgress(ctx, job.JobID, currentProgress, currentAttachIds); err != nil {
 					log.Printf("⚠️  Failed to update progress: %v", err)
@@ -461,7 +469,7 @@ func processSingleBatch(ctx context.Context, service *Service, job *model.Produc
 	retryAttempt := 0
 	cancelled := false
 
-	for completedCount < job.TotalImages && retryAttempt < maxRetries && !service.IsJobCancelled(job.JobID) {
+	for completedCount < job.TotalImages && retryAttempt < maxRetries && !cancel.JobCancelled(ctx, service, job.JobID) {
 		retryAttempt++
 		remaining := job.TotalImages - completedCount
 
@@ -492,7 +500,7 @@ func processSingleBatch(ctx context.Context, service *Service, job *model.Produc
 		// 부족한 개수만큼 생성
 		for i := 0; i < remaining; i++ {
 			// 취소 체크
-			if service.IsJobCancelled(job.JobID) {
+			if cancel.JobCancelled(ctx, service, job.JobID) {
 				log.Printf("🛑 Job %s cancelled during retry", job.JobID)
 				cancelled = true
 				break
@@ -569,7 +577,7 @@ func processSingleBatch(ctx context.Context, service *Service, job *model.Produc
 
 	// Phase 5: 최종 완료 처리
 	// 🛑 취소된 Job은 user_cancelled 상태 유지 (completed로 덮어쓰지 않음)
-	if service.IsJobCancelled(job.JobID) || cancelled {
+	if cancel.JobCancelled(ctx, service, job.JobID) || cancelled {
 		log.Printf("🛑 Job %s was cancelled, keeping user_cancelled status", job.JobID)
 		// attach_ids만 업데이트 (이미 생성된 이미지들)
 		if job.ProductionID != nil && len(generatedAttachIds) > 0 {
@@ -831,7 +839,7 @@ func processPipelineStage(ctx context.Context, service *Service, job *model.Prod
 
 			for i := 0; i < quantity; i++ {
 				// 🛑 취소 체크 - 새 이미지 생성 전에 확인
-				if service.IsJobCancelled(job.JobID) {
+				if cancel.JobCancelled(ctx, service, job.JobID) {
 					log.Printf("🛑 Stage %d: Job %s cancelled, stopping generation", stageIndex, job.JobID)
 					// 지금까지 생성된 이미지는 results에 저장
 					results[stageIndex] = cancel.StageResult{
@@ -878,7 +886,7 @@ func processPipelineStage(ctx context.Context, service *Service, job *model.Prod
 				}
 
 				// 🛑 Gemini 응답 후 취소 체크 - 취소됐으면 저장/차감 안 함
-				if service.IsJobCancelled(job.JobID) {
+				if cancel.JobCancelled(ctx, service, job.JobID) {
 					log.Printf("🛑 Stage %d: Job %s cancelled after generation, discarding image %d", stageIndex, job.JobID, i+1)
 					// 지금까지 생성된 이미지는 results에 저장
 					results[stageIndex] = cancel.StageResult{
@@ -987,7 +995,7 @@ func processPipelineStage(ctx context.Context, service *Service, job *model.Prod
 	// Step 2: 부족한 Stage만 재시도
 	for stageIdx, stageData := range stages {
 		// 🛑 재시도 전에 취소 체크
-		if service.IsJobCancelled(job.JobID) {
+		if cancel.JobCancelled(ctx, service, job.JobID) {
 			log.Printf("🛑 Job %s cancelled, skipping retry phase", job.JobID)
 			break
 		}
@@ -1067,7 +1075,7 @@ func processPipelineStage(ctx context.Context, service *Service, job *model.Prod
 		retrySuccess := 0
 		for i := 0; i < missing; i++ {
 			// 🛑 취소 체크
-			if service.IsJobCancelled(job.JobID) {
+			if cancel.JobCancelled(ctx, service, job.JobID) {
 				log.Printf("🛑 Stage %d: Job %s cancelled during retry", stageIdx, job.JobID)
 				service.UpdateJobStatus(ctx, job.JobID, model.StatusUserCancelled)
 				if job.ProductionID != nil {
@@ -1187,7 +1195,7 @@ func processPipelineStage(ctx context.Context, service *Service, job *model.Prod
 
 	// Phase 4: 최종 완료 처리
 	// 🛑 Cancel된 job은 상태를 덮어쓰지 않음
-	if service.IsJobCancelled(job.JobID) {
+	if cancel.JobCancelled(ctx, service, job.JobID) {
 		log.Printf("🛑 Job %s was cancelled, keeping user_cancelled status", job.JobID)
 		// attach_ids만 업데이트 (이미 생성된 이미지들)
 		if job.ProductionID != nil && len(allGeneratedAttachIds) > 0 {
@@ -1351,7 +1359,7 @@ func processSimpleGeneral(ctx context.Context, service *Service, job *model.Prod
 
 	for i := 0; i < quantity; i++ {
 		// 🛑 취소 체크
-		if service.IsJobCancelled(job.JobID) {
+		if cancel.JobCancelled(ctx, service, job.JobID) {
 			log.Printf("🛑 Job %s cancelled, stopping generation", job.JobID)
 			service.UpdateJobStatus(ctx, job.JobID, model.StatusUserCancelled)
 			if job.ProductionID != nil {
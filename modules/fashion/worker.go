@@ -12,8 +12,10 @@ import (
 
 	"github.com/redis/go-redis/v9"
 
+	"quel-canvas-server/modules/common/cancel"
 	"quel-canvas-server/modules/common/config"
 	"quel-canvas-server/modules/common/fallback"
+	"quel-canvas-server/modules/common/jobevents"
 	"quel-canvas-server/modules/common/model"
 )
 
@@ -305,7 +307,7 @@ func processSingleBatch(ctx context.Context, service *Service, job *model.Produc
 			// 해당 조합의 quantity만큼 생성
 			for i := 0; i < quantity; i++ {
 				// 🛑 취소 체크 - 새 이미지 생성 전에 확인
-				if service.IsJobCancelled(job.JobID) {
+				if cancel.JobCancelled(ctx, service, job.JobID) {
 					log.Printf("🛑 Combination %d: Job %s cancelled, stopping generation", idx+1, job.JobID)
 					progressMutex.Lock()
 					cancelled = true
@@ -319,8 +321,8 @@ func processSingleBatch(ctx context.Context, service *Service, job *model.Produc
 				// shot에 따라 에셋 필터링 (tight → pants, shoes 제거)
 				filteredCategories := filterCategoriesByShot(categories, shot, clothingItemTypes, accessoryItemTypes)
 
-				// 병렬 처리 레이트 리밋 방지: 3초 딜레이
-				time.Sleep(3 * time.Second)
+				// 병렬 처리 레이트 리밋 방지: 3초 딜레이 (취소되면 바로 깨어남)
+				cancel.Sleep(ctx, 3*time.Second)
 
 				// Gemini API 호출 (카테고리별 이미지 전달, aspect-ratio 포함, shot 전달)
 				generatedBase64, err := service.GenerateImageWithGeminiMultiple(ctx, filteredCategories, enhancedPrompt, aspectRatio, shot)
@@ -330,7 +332,7 @@ func processSingleBatch(ctx context.Context, service *Service, job *model.Produc
 				}
 
 				// 🛑 취소 체크 - 이미지 생성 후, 저장 전에 확인
-				if service.IsJobCancelled(job.JobID) {
+				if cancel.JobCancelled(ctx, service, job.JobID) {
 					log.Printf("🛑 Combination %d: Job %s cancelled after generation, discarding image %d", idx+1, job.JobID, i+1)
 					progressMutex.Lock()
 					cancelled = true
@@ -380,6 +382,13 @@ func processSingleBatch(ctx context.Context, service *Service, job *model.Produc
 				log.Printf("Combination %d: Image %d/%d completed for [%s + %s]: AttachID=%d",
 					idx+1, i+1, quantity, angle, shot, attachID)
 
+				// SSE/Room 이벤트에 조합 정보 포함
+				jobevents.DescribeImage(attachID, map[string]interface{}{
+					"combination": idx + 1,
+					"angle":       angle,
+					"shot":        shot,
+				})
+
 				// 진행 상황 업데이트
 				if err := service.UpdateJobProgress(ctx, job.JobID, currentProgress, currentAttachIds); err != nil {
 					log.Printf("Failed to update progress: %v", err)
@@ -439,7 +448,7 @@ func processSingleBatch(ctx context.Context, service *Service, job *model.Produc
 		// 부족한 개수만큼 생성
 		for i := 0; i < remaining; i++ {
 			// 취소 체크
-			if service.IsJobCancelled(job.JobID) {
+			if cancel.JobCancelled(ctx, service, job.JobID) {
 				log.Printf("🛑 Job %s cancelled during retry", job.JobID)
 				progressMutex.Lock()
 				cancelled = true
@@ -10,6 +10,8 @@ import (
 	"time"
 
 	"github.com/redis/go-redis/v9"
+
+	"quel-canvas-server/modules/common/jobevents"
 )
 
 // StartWorker - Redis Queue Worker 시작
@@ -343,6 +345,13 @@ func processSingleBatch(ctx context.Context, service *Service, job *ProductionJo
 				log.Printf("✅ Combination %d: Image %d/%d completed for [%s + %s]: AttachID=%d",
 					idx+1, i+1, quantity, angle, shot, attachID)
 
+				// SSE/Room 이벤트에 조합 정보 포함
+				jobevents.DescribeImage(attachID, map[string]interface{}{
+					"combination": idx + 1,
+					"angle":       angle,
+					"shot":        shot,
+				})
+
 				// 진행 상황 업데이트
 				if err := service.UpdateJobProgress(ctx, job.JobID, currentProgress, currentAttachIds); err != nil {
 					log.Printf("⚠️  Failed to update progress: %v", err)
@@ -2,6 +2,7 @@ package klingmigration
 
 import (
 	"bytes"
+	"context"
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/base64"
@@ -11,6 +12,8 @@ import (
 	"log"
 	"net/http"
 	"time"
+
+	"quel-canvas-server/modules/common/cancel"
 )
 
 // Service - Kling AI API 서비스
@@ -128,7 +131,7 @@ func (s *Service) CreateImageToVideoTask(imageBase64, prompt string) (string, er
 }
 
 // GetTaskStatus - 작업 상태 조회
-func (s *Service) GetTaskStatus(taskID string) (*KlingTaskStatusResponse, error) {
+func (s *Service) GetTaskStatus(ctx context.Context, taskID string) (*KlingTaskStatusResponse, error) {
 	jwt, err := s.generateJWT()
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate JWT: %w", err)
@@ -137,7 +140,7 @@ func (s *Service) GetTaskStatus(taskID string) (*KlingTaskStatusResponse, error)
 	// 상태 조회 URL
 	statusURL := fmt.Sprintf("https://api.klingai.com/v1/videos/image2video/%s", taskID)
 
-	req, err := http.NewRequest("GET", statusURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", statusURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -167,15 +170,17 @@ func (s *Service) GetTaskStatus(taskID string) (*KlingTaskStatusResponse, error)
 	return &result, nil
 }
 
-// WaitForCompletion - 작업 완료 대기 (폴링)
-func (s *Service) WaitForCompletion(taskID string, maxAttempts int) (*KlingTaskStatusResponse, error) {
+// WaitForCompletion - 작업 완료 대기 (폴링, ctx가 취소되면 바로 중단)
+func (s *Service) WaitForCompletion(ctx context.Context, taskID string, maxAttempts int) (*KlingTaskStatusResponse, error) {
 	log.Printf("⏳ [Kling] Waiting for task %s to complete...", taskID)
 
 	for attempt := 1; attempt <= maxAttempts; attempt++ {
-		status, err := s.GetTaskStatus(taskID)
+		status, err := s.GetTaskStatus(ctx, taskID)
 		if err != nil {
 			log.Printf("⚠️ [Kling] Attempt %d: Failed to get status: %v", attempt, err)
-			time.Sleep(5 * time.Second)
+			if !cancel.Sleep(ctx, 5*time.Second) {
+				return nil, context.Cause(ctx)
+			}
 			continue
 		}
 
@@ -189,10 +194,12 @@ func (s *Service) WaitForCompletion(taskID string, maxAttempts int) (*KlingTaskS
 			return status, fmt.Errorf("task failed: %s", status.Message)
 		case "submitted", "processing":
 			// 계속 대기
-			time.Sleep(5 * time.Second)
 		default:
 			log.Printf("⚠️ [Kling] Unknown status: %s", status.Data.TaskStatus)
-			time.Sleep(5 * time.Second)
+		}
+
+		if !cancel.Sleep(ctx, 5*time.Second) {
+			return nil, context.Cause(ctx)
 		}
 	}
 
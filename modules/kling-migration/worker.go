@@ -9,8 +9,11 @@ import (
 	"time"
 
 	"github.com/redis/go-redis/v9"
+	"quel-canvas-server/modules/common/cancel"
 	appconfig "quel-canvas-server/modules/common/config"
 	"quel-canvas-server/modules/common/database"
+	"quel-canvas-server/modules/common/jobevents"
+	"quel-canvas-server/modules/common/model"
 	redisClient "quel-canvas-server/modules/common/redis"
 )
 
@@ -64,6 +67,9 @@ func (w *Worker) StartWorker() {
 	log.Println("🔄 [Kling Worker] Starting video queue worker...")
 	log.Println("👀 [Kling Worker] Watching queue: jobs:video")
 
+	// Job 취소 알림 구독 (취소되면 Kling 상태 폴링 즉시 중단)
+	cancel.Watch(w.rdb)
+
 	ctx := context.Background()
 
 	for {
@@ -97,6 +103,10 @@ func (w *Worker) processVideoJob(ctx context.Context, jobID string) {
 
 	log.Printf("📦 [Kling Worker] Job Data - Type: %s, Status: %s", job.JobType, job.JobStatus)
 
+	// 진행 이벤트를 보낼 org/workspace/요청자 등록 (started/failed/completed/summary → SSE, 웹훅)
+	jobevents.Track(job)
+	defer jobevents.Forget(jobID)
+
 	// 2. Job 상태를 processing으로 업데이트
 	if err := w.dbClient.UpdateJobStatus(ctx, jobID, "processing"); err != nil {
 		log.Printf("⚠️ [Kling Worker] Failed to update job status: %v", err)
@@ -126,8 +136,16 @@ func (w *Worker) processVideoJob(ctx context.Context, jobID string) {
 
 	log.Printf("✅ [Kling Worker] Kling task created: %s", taskID)
 
-	// 5. Kling AI 작업 완료 대기 (최대 60회 시도 = 약 5분)
-	status, err := w.service.WaitForCompletion(taskID, 60)
+	// 5. Kling AI 작업 완료 대기 (최대 60회 시도 = 약 5분, 사용자 취소 시 즉시 중단)
+	jobCtx, release := cancel.WithJob(ctx, jobID)
+	status, err := w.service.WaitForCompletion(jobCtx, taskID, 60)
+	cancelled := cancel.IsCancelled(jobCtx)
+	release()
+	if cancelled {
+		log.Printf("🛑 [Kling Worker] Job %s cancelled while waiting for task %s", jobID, taskID)
+		w.dbClient.UpdateJobStatus(ctx, jobID, model.StatusUserCancelled)
+		return
+	}
 	if err != nil {
 		log.Printf("❌ [Kling Worker] Task failed or timed out: %v", err)
 		w.dbClient.UpdateJobFailed(ctx, jobID, err.Error())
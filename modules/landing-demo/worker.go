@@ -9,6 +9,7 @@ import (
 
 	"google.golang.org/genai"
 
+	"quel-canvas-server/modules/common/cancel"
 	"quel-canvas-server/modules/common/config"
 	"quel-canvas-server/modules/common/fallback"
 	geminiretry "quel-canvas-server/modules/common/gemini"
@@ -212,7 +213,7 @@ func processLandingSimpleGeneral(ctx context.Context, service *Service, job *mod
 	for i := 0; i < quantity; i++ {
 		go func(idx int) {
 			// 취소 체크
-			if service.IsJobCancelled(job.JobID) {
+			if cancel.JobCancelled(ctx, service, job.JobID) {
 				resultChan <- GenerationResult{Index: idx, Error: fmt.Errorf("job cancelled")}
 				return
 			}
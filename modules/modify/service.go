@@ -14,7 +14,10 @@ import (
 	"google.golang.org/genai"
 
 	"quel-canvas-server/modules/common/config"
+	"quel-canvas-server/modules/common/jobevents"
+	"quel-canvas-server/modules/common/model"
 	"quel-canvas-server/modules/common/org"
+	jobqueue "quel-canvas-server/modules/common/queue"
 )
 
 type Service struct {
@@ -458,13 +461,27 @@ func (s *Service) CreateJobAndEnqueue(jobID, productionID string, inputData Modi
 		WriteTimeout: 30 * time.Second,
 	})
 
-	// Redis Queue에 Job ID만 추가 (worker가 Supabase에서 전체 데이터를 조회)
-	err = redisClient.LPush(ctx, "jobs:queue", jobID).Err()
+	defer redisClient.Close()
+
+	// enqueue API와 같은 레인/소유자 대기열에 Job ID만 추가 (worker가 Supabase에서 전체 데이터를 조회)
+	firstSeen, err := jobqueue.MarkSeen(ctx, redisClient, jobID, cfg.JobDedupTTL, "")
+	if err != nil {
+		return fmt.Errorf("failed to check job dedup: %w", err)
+	}
+	if !firstSeen {
+		log.Printf("⏭️ Job %s already enqueued - skipping", jobID)
+		return nil
+	}
+
+	queued := &model.ProductionJob{JobID: jobID, QuelMemberID: &inputData.UserID}
+	lane, position, err := jobqueue.Enqueue(ctx, redisClient, queued)
 	if err != nil {
+		// 다음 재요청이 다시 넣을 수 있도록 중복 기록 제거
+		redisClient.Del(ctx, jobqueue.SeenKey(jobID))
 		return fmt.Errorf("failed to enqueue job: %w", err)
 	}
 
-	log.Printf("✅ Job enqueued to Redis: %s", jobID)
+	log.Printf("✅ Job enqueued to Redis: %s (lane: %s, position: %d)", jobID, lane, position)
 	return nil
 }
 
@@ -522,6 +539,7 @@ func (s *Service) UpdateJobStatus(ctx context.Context, jobID string, status stri
 	}
 
 	log.Printf("✅ Job %s status updated to: %s", jobID, status)
+	jobevents.JobStatus(jobID, status)
 	return nil
 }
 
@@ -531,8 +549,10 @@ func (s *Service) UpdateJobProgress(ctx context.Context, jobID string, completed
 
 	// generated_attach_ids를 interface{} 배열로 변환
 	attachIDsInterface := make([]interface{}, len(attachIDs))
+	attachIDsInt := make([]int, len(attachIDs))
 	for i, id := range attachIDs {
 		attachIDsInterface[i] = id
+		attachIDsInt[i] = int(id)
 	}
 
 	// Job 업데이트
@@ -563,5 +583,6 @@ func (s *Service) UpdateJobProgress(ctx context.Context, jobID string, completed
 		log.Printf("⚠️  Failed to update production attach_ids: %v", err)
 	}
 
+	jobevents.ImageDone(jobID, completed, attachIDsInt)
 	return nil
 }
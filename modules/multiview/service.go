@@ -21,6 +21,7 @@ import (
 
 	"quel-canvas-server/modules/common/config"
 	geminiretry "quel-canvas-server/modules/common/gemini"
+	"quel-canvas-server/modules/common/jobevents"
 	"quel-canvas-server/modules/common/model"
 	"quel-canvas-server/modules/common/org"
 	redisutil "quel-canvas-server/modules/common/redis"
@@ -54,6 +55,14 @@ func NewService() *Service {
 	}
 }
 
+// IsJobCancelled - Job 취소 여부 확인
+func (s *Service) IsJobCancelled(jobID string) bool {
+	if s.redis == nil {
+		return false
+	}
+	return redisutil.IsJobCancelled(s.redis, jobID)
+}
+
 // GenerateMultiview - 360도 다각도 이미지 생성 (동기 방식)
 func (s *Service) GenerateMultiview(ctx context.Context, req *MultiviewGenerateRequest) (*MultiviewGenerateResponse, error) {
 	cfg := config.GetConfig()
@@ -693,6 +702,7 @@ func (s *Service) CreateAttachRecord(ctx context.Context, filePath string, fileS
 	attachID := int(attaches[0].AttachID)
 	log.Printf("✅ [Multiview] Attach record created: ID=%d", attachID)
 
+	jobevents.AttachCreated(attachID, filePath)
 	return attachID, nil
 }
 
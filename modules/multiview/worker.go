@@ -8,11 +8,12 @@ import (
 	"sync"
 	"time"
 
+	"quel-canvas-server/modules/common/cancel"
 	"quel-canvas-server/modules/common/config"
 	"quel-canvas-server/modules/common/database"
 	geminiretry "quel-canvas-server/modules/common/gemini"
+	"quel-canvas-server/modules/common/jobevents"
 	"quel-canvas-server/modules/common/model"
-	redisutil "quel-canvas-server/modules/common/redis"
 
 	"github.com/redis/go-redis/v9"
 	"google.golang.org/genai"
@@ -197,8 +198,7 @@ func processMultiview360(ctx context.Context, service *Service, job *model.Produ
 			log.Printf("🎨 [Multiview] Generating angle %d (%s)...", currentAngle, GetAngleLabel(currentAngle))
 
 			// Job 취소 확인
-			rdb := redisutil.Connect(cfg)
-			if rdb != nil && redisutil.IsJobCancelled(rdb, job.JobID) {
+			if cancel.JobCancelled(ctx, service, job.JobID) {
 				log.Printf("⚠️ [Multiview] Job cancelled, skipping angle %d", currentAngle)
 				return
 			}
@@ -237,8 +237,11 @@ func processMultiview360(ctx context.Context, service *Service, job *model.Produ
 					hasReference = true
 				}
 
-				// 병렬 처리 레이트 리밋 방지: 3초 딜레이
-				time.Sleep(3 * time.Second)
+				// 병렬 처리 레이트 리밋 방지: 3초 딜레이 (취소되면 바로 깨어나서 이 각도는 건너뜀)
+				if !cancel.Sleep(ctx, 3*time.Second) {
+					log.Printf("⚠️ [Multiview] Job cancelled during delay, skipping angle %d", currentAngle)
+					return
+				}
 
 				imageData, err := service.GenerateSingleAngle(ctx, sourceImageData, refData, currentAngle, aspectRatio, category, originalPrompt, hasReference, rotateBackground)
 				if err != nil {
@@ -285,6 +288,10 @@ func processMultiview360(ctx context.Context, service *Service, job *model.Produ
 			generatedImages = append(generatedImages, result)
 			if result.Success && result.AttachID > 0 {
 				generatedAttachIDs = append(generatedAttachIDs, result.AttachID)
+				jobevents.DescribeImage(result.AttachID, map[string]interface{}{
+					"angle":      result.Angle,
+					"angleLabel": result.AngleLabel,
+				})
 			}
 			mu.Unlock()
 
@@ -308,20 +315,35 @@ func processMultiview360(ctx context.Context, service *Service, job *model.Produ
 
 	wg.Wait()
 
-	// Phase 7: 크레딧 한번에 차감 (동시성 문제 방지)
+	// Phase 7: 크레딧 한번에 차감 (동시성 문제 방지, 취소돼도 이미 생성된 이미지는 차감)
 	if totalCreditsUsed > 0 {
 		log.Printf("💰 [Multiview] Deducting total credits: %d", totalCreditsUsed)
 		productionID := ""
 		if job.ProductionID != nil {
 			productionID = *job.ProductionID
 		}
-		if err := service.DeductCredits(ctx, userID, totalCreditsUsed, productionID); err != nil {
+		if err := service.DeductCredits(context.WithoutCancel(ctx), userID, totalCreditsUsed, productionID); err != nil {
 			log.Printf("⚠️ [Multiview] Failed to deduct credits: %v", err)
 		} else {
 			log.Printf("✅ [Multiview] Successfully deducted %d credits", totalCreditsUsed)
 		}
 	}
 
+	// 사용자 취소: 완료/실패 대신 user_cancelled로 종료 (Job 컨텍스트는 이미 취소됐으므로 상태 저장은 취소와 무관하게)
+	if cancel.JobCancelled(ctx, service, job.JobID) {
+		log.Printf("🛑 [Multiview] Job %s cancelled (%d/%d angles generated)", job.JobID, len(generatedAttachIDs), len(angles))
+		saveCtx := context.WithoutCancel(ctx)
+		if err := dbClient.UpdateJobStatus(saveCtx, job.JobID, model.StatusUserCancelled); err != nil {
+			log.Printf("⚠️ [Multiview] Failed to update job cancelled: %v", err)
+		}
+		if job.ProductionID != nil && *job.ProductionID != "" {
+			if err := dbClient.UpdateProductionPhotoStatus(saveCtx, *job.ProductionID, model.StatusUserCancelled); err != nil {
+				log.Printf("⚠️ [Multiview] Failed to update production status: %v", err)
+			}
+		}
+		return
+	}
+
 	// Phase 8: Job 완료 상태 업데이트
 	successCount := 0
 	for _, img := range generatedImages {
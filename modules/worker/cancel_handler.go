@@ -72,14 +72,7 @@ func (h *CancelHandler) CancelJob(w http.ResponseWriter, r *http.Request) {
 
 	log.Printf("🛑 [CancelHandler] Cancel requested for job: %s", jobID)
 
-	// 1. Redis에 취소 플래그 설정
-	if err := redisutil.SetJobCancelled(h.rdb, jobID); err != nil {
-		log.Printf("❌ [CancelHandler] Failed to set cancel flag: %v", err)
-		http.Error(w, `{"error": "Failed to set cancel flag"}`, http.StatusInternalServerError)
-		return
-	}
-
-	// 2. DB에서 현재 job 상태 조회
+	// 1. DB에서 현재 job 상태 조회 (없는 Job이나 끝난 Job에는 취소 플래그/알림을 남기지 않음)
 	var jobs []model.ProductionJob
 	_, err := h.supabase.From("quel_production_jobs").
 		Select("*", "", false).
@@ -108,6 +101,18 @@ func (h *CancelHandler) CancelJob(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// 2. Redis에 취소 플래그 설정
+	if err := redisutil.SetJobCancelled(h.rdb, jobID); err != nil {
+		log.Printf("❌ [CancelHandler] Failed to set cancel flag: %v", err)
+		http.Error(w, `{"error": "Failed to set cancel flag"}`, http.StatusInternalServerError)
+		return
+	}
+
+	// 처리 중인 워커에 알림 (Job 컨텍스트 취소, 실패해도 플래그로 다음 확인 때 중단)
+	if err := redisutil.PublishJobCancelled(h.rdb, jobID); err != nil {
+		log.Printf("⚠️ [CancelHandler] Failed to publish cancel for job %s: %v", jobID, err)
+	}
+
 	log.Printf("✅ [CancelHandler] Cancel flag set for job: %s (current status: %s, completed: %d)",
 		jobID, job.JobStatus, job.CompletedImages)
 
@@ -115,7 +120,7 @@ func (h *CancelHandler) CancelJob(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"success":          true,
-		"message":          "Cancel request sent. Job will stop after current image.",
+		"message":          "Cancel request sent. In-flight generation will be aborted.",
 		"job_id":           jobID,
 		"current_status":   job.JobStatus,
 		"completed_images": job.CompletedImages,
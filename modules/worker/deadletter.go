@@ -0,0 +1,144 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Dead-letter Redis 키
+// jobs:deadletter - jobID → deadLetterEntry (JSON)
+// jobs:deadletter:index - jobID (score: 마지막 실패 시각, 최신순 목록용)
+// jobs:attempts - jobID → 시도 횟수 (조회 실패 포함, 폐기 또는 정상 종료 시 삭제)
+const (
+	deadLetterKey      = "jobs:deadletter"
+	deadLetterIndexKey = "jobs:deadletter:index"
+	jobAttemptsKey     = "jobs:attempts"
+)
+
+// deadLetterEntry - 처리하지 못하고 버려질 뻔한 Job 기록
+type deadLetterEntry struct {
+	JobID         string    `json:"jobId"`
+	Reason        string    `json:"reason"`
+	Attempts      int64     `json:"attempts"`
+	Path          string    `json:"path,omitempty"`
+	WorkerID      string    `json:"workerId"`
+	FirstFailedAt time.Time `json:"firstFailedAt"`
+	LastFailedAt  time.Time `json:"lastFailedAt"`
+}
+
+// recordAttempt - Job 시도 횟수 증가 (반환: 이번 시도 번호)
+func recordAttempt(ctx context.Context, rdb *redis.Client, jobID string) int64 {
+	attempts, err := rdb.HIncrBy(ctx, jobAttemptsKey, jobID, 1).Result()
+	if err != nil {
+		log.Printf("⚠️ [DeadLetter] Failed to count attempt for %s: %v", jobID, err)
+		return 1
+	}
+	return attempts
+}
+
+// clearAttempts - 정상적으로 끝난 Job의 시도 횟수 삭제
+func clearAttempts(ctx context.Context, rdb *redis.Client, jobID string) {
+	rdb.HDel(ctx, jobAttemptsKey, jobID)
+}
+
+// addDeadLetter - dead-letter 목록에 기록 (이미 있으면 사유/시각/시도 횟수 갱신)
+func addDeadLetter(ctx context.Context, rdb *redis.Client, workerID string, jobID string, path string, reason string) error {
+	now := time.Now()
+	entry := deadLetterEntry{
+		JobID:         jobID,
+		Reason:        reason,
+		Path:          path,
+		WorkerID:      workerID,
+		FirstFailedAt: now,
+		LastFailedAt:  now,
+	}
+	if previous, err := getDeadLetter(ctx, rdb, jobID); err == nil && previous != nil {
+		entry.FirstFailedAt = previous.FirstFailedAt
+	}
+	if attempts, err := rdb.HGet(ctx, jobAttemptsKey, jobID).Int64(); err == nil {
+		entry.Attempts = attempts
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	pipe := rdb.TxPipeline()
+	pipe.HSet(ctx, deadLetterKey, jobID, data)
+	pipe.ZAdd(ctx, deadLetterIndexKey, redis.Z{Score: float64(now.UnixMilli()), Member: jobID})
+	if _, err := pipe.Exec(ctx); err != nil {
+		return err
+	}
+
+	log.Printf("☠️ [DeadLetter] Job %s moved to dead-letter after %d attempts: %s", jobID, entry.Attempts, reason)
+	return nil
+}
+
+// getDeadLetter - dead-letter 항목 조회 (없으면 nil)
+func getDeadLetter(ctx context.Context, rdb *redis.Client, jobID string) (*deadLetterEntry, error) {
+	data, err := rdb.HGet(ctx, deadLetterKey, jobID).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var entry deadLetterEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, fmt.Errorf("failed to parse dead-letter entry: %w", err)
+	}
+	return &entry, nil
+}
+
+// listDeadLetters - 최근 실패 순으로 dead-letter 목록 (offset부터 limit개)
+func listDeadLetters(ctx context.Context, rdb *redis.Client, offset int64, limit int64) ([]deadLetterEntry, int64, error) {
+	total, err := rdb.ZCard(ctx, deadLetterIndexKey).Result()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	jobIDs, err := rdb.ZRevRange(ctx, deadLetterIndexKey, offset, offset+limit-1).Result()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	entries := make([]deadLetterEntry, 0, len(jobIDs))
+	for _, jobID := range jobIDs {
+		entry, err := getDeadLetter(ctx, rdb, jobID)
+		if err != nil {
+			return nil, 0, err
+		}
+		if entry != nil {
+			entries = append(entries, *entry)
+		}
+	}
+	return entries, total, nil
+}
+
+// removeDeadLetter - dead-letter에서 제거 (반환: 제거 여부)
+func removeDeadLetter(ctx context.Context, rdb *redis.Client, jobID string) (bool, error) {
+	pipe := rdb.TxPipeline()
+	removed := pipe.HDel(ctx, deadLetterKey, jobID)
+	pipe.ZRem(ctx, deadLetterIndexKey, jobID)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return false, err
+	}
+	return removed.Val() > 0, nil
+}
+
+// deadLetter - 이 워커에서 실패한 Job을 dead-letter에 기록
+func (q *reliableQueue) deadLetter(jobID string, path string, reason string) {
+	ctx, cancel := context.WithTimeout(context.Background(), queueOpTimeout)
+	defer cancel()
+
+	if err := addDeadLetter(ctx, q.rdb, q.workerID, jobID, path, reason); err != nil {
+		log.Printf("❌ [DeadLetter] Failed to record job %s: %v", jobID, err)
+	}
+}
@@ -0,0 +1,170 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/redis/go-redis/v9"
+	"quel-canvas-server/modules/common/database"
+	"quel-canvas-server/modules/common/model"
+)
+
+// DeadLetterHandler - dead-letter 조회/재시도/폐기 관리자 API
+type DeadLetterHandler struct {
+	rdb      *redis.Client
+	dbClient *database.Client
+}
+
+// NewDeadLetterHandler - DeadLetterHandler 생성 (rdb: main에서 만든 공용 Redis 클라이언트)
+func NewDeadLetterHandler(rdb *redis.Client) *DeadLetterHandler {
+	if rdb == nil {
+		log.Println("⚠️ [DeadLetter] Redis not connected")
+		return nil
+	}
+
+	dbClient := database.NewClient()
+	if dbClient == nil {
+		log.Println("⚠️ [DeadLetter] Failed to initialize Database client")
+		return nil
+	}
+
+	return &DeadLetterHandler{
+		rdb:      rdb,
+		dbClient: dbClient,
+	}
+}
+
+// RegisterRoutes - 라우트 등록 (auth: 관리자 인증 미들웨어)
+func (h *DeadLetterHandler) RegisterRoutes(r *mux.Router, auth func(http.HandlerFunc) http.HandlerFunc) {
+	r.HandleFunc("/admin/jobs/dead-letter", auth(h.List)).Methods("GET")
+	r.HandleFunc("/admin/jobs/dead-letter/{jobId}", auth(h.Get)).Methods("GET")
+	r.HandleFunc("/admin/jobs/dead-letter/{jobId}/requeue", auth(h.Requeue)).Methods("POST")
+	r.HandleFunc("/admin/jobs/dead-letter/{jobId}", auth(h.Discard)).Methods("DELETE")
+	log.Println("✅ [DeadLetter] Routes registered: /admin/jobs/dead-letter")
+}
+
+// List - GET /admin/jobs/dead-letter?offset=0&limit=50
+func (h *DeadLetterHandler) List(w http.ResponseWriter, r *http.Request) {
+	offset, _ := strconv.ParseInt(r.URL.Query().Get("offset"), 10, 64)
+	if offset < 0 {
+		offset = 0
+	}
+	limit, _ := strconv.ParseInt(r.URL.Query().Get("limit"), 10, 64)
+	if limit <= 0 || limit > 500 {
+		limit = 50
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	entries, total, err := listDeadLetters(ctx, h.rdb, offset, limit)
+	if err != nil {
+		log.Printf("❌ [DeadLetter] Failed to list entries: %v", err)
+		http.Error(w, `{"error": "Failed to list dead-letter jobs"}`, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"total":   total,
+		"offset":  offset,
+		"entries": entries,
+	})
+}
+
+// Get - GET /admin/jobs/dead-letter/{jobId} (dead-letter 기록 + 현재 Job 상태)
+func (h *DeadLetterHandler) Get(w http.ResponseWriter, r *http.Request) {
+	jobID := mux.Vars(r)["jobId"]
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	entry, err := getDeadLetter(ctx, h.rdb, jobID)
+	if err != nil {
+		log.Printf("❌ [DeadLetter] Failed to read %s: %v", jobID, err)
+		http.Error(w, `{"error": "Failed to read dead-letter job"}`, http.StatusInternalServerError)
+		return
+	}
+	if entry == nil {
+		http.Error(w, `{"error": "Job not in dead-letter"}`, http.StatusNotFound)
+		return
+	}
+
+	response := map[string]interface{}{"entry": entry}
+	if job, err := h.dbClient.FetchJobFromSupabase(jobID); err == nil {
+		response["job"] = job
+	} else {
+		response["jobError"] = err.Error()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// Requeue - POST /admin/jobs/dead-letter/{jobId}/requeue (pending으로 되돌리고 retry 대기열 맨 앞에)
+func (h *DeadLetterHandler) Requeue(w http.ResponseWriter, r *http.Request) {
+	jobID := mux.Vars(r)["jobId"]
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	entry, err := getDeadLetter(ctx, h.rdb, jobID)
+	if err != nil {
+		http.Error(w, `{"error": "Failed to read dead-letter job"}`, http.StatusInternalServerError)
+		return
+	}
+	if entry == nil {
+		http.Error(w, `{"error": "Job not in dead-letter"}`, http.StatusNotFound)
+		return
+	}
+
+	if err := h.dbClient.UpdateJobStatus(ctx, jobID, model.StatusPending); err != nil {
+		log.Printf("❌ [DeadLetter] Failed to reset job %s to pending: %v", jobID, err)
+		http.Error(w, `{"error": "Failed to reset job status"}`, http.StatusInternalServerError)
+		return
+	}
+	if err := h.rdb.RPush(ctx, queueKey, jobID).Err(); err != nil {
+		log.Printf("❌ [DeadLetter] Failed to requeue job %s: %v", jobID, err)
+		http.Error(w, `{"error": "Failed to requeue job"}`, http.StatusInternalServerError)
+		return
+	}
+	if _, err := removeDeadLetter(ctx, h.rdb, jobID); err != nil {
+		log.Printf("⚠️ [DeadLetter] Requeued job %s but failed to remove entry: %v", jobID, err)
+	}
+
+	log.Printf("♻️ [DeadLetter] Job %s requeued by admin (%d previous attempts)", jobID, entry.Attempts)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":  true,
+		"jobId":    jobID,
+		"attempts": entry.Attempts,
+	})
+}
+
+// Discard - DELETE /admin/jobs/dead-letter/{jobId} (재시도하지 않고 기록만 삭제)
+func (h *DeadLetterHandler) Discard(w http.ResponseWriter, r *http.Request) {
+	jobID := mux.Vars(r)["jobId"]
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	removed, err := removeDeadLetter(ctx, h.rdb, jobID)
+	if err != nil {
+		http.Error(w, `{"error": "Failed to discard dead-letter job"}`, http.StatusInternalServerError)
+		return
+	}
+	if !removed {
+		http.Error(w, `{"error": "Job not in dead-letter"}`, http.StatusNotFound)
+		return
+	}
+	clearAttempts(ctx, h.rdb, jobID)
+
+	log.Printf("🗑️ [DeadLetter] Job %s discarded by admin", jobID)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"success": true, "jobId": jobID})
+}
@@ -3,6 +3,7 @@ package worker
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
 	"time"
@@ -10,12 +11,24 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/redis/go-redis/v9"
 	"quel-canvas-server/modules/common/config"
+	"quel-canvas-server/modules/common/database"
+	"quel-canvas-server/modules/common/model"
+	jobqueue "quel-canvas-server/modules/common/queue"
 	redisClient "quel-canvas-server/modules/common/redis"
 )
 
 // EnqueueHandler - Redis Queue Enqueue Handler
 type EnqueueHandler struct {
-	rdb *redis.Client
+	rdb      *redis.Client
+	dbClient *database.Client // 레인 결정/상태 확인용 Job 조회
+	dedupTTL time.Duration    // 같은 job_id 재요청을 중복으로 보는 기간
+}
+
+// 다시 넣지 않는 상태 (이미 실행 중이거나 끝난 Job)
+var nonEnqueueableStatuses = map[string]bool{
+	model.StatusProcessing:    true,
+	model.StatusCompleted:     true,
+	model.StatusUserCancelled: true,
 }
 
 // EnqueueRequest - Enqueue 요청
@@ -30,7 +43,11 @@ type EnqueueResponse struct {
 	Error         string `json:"error,omitempty"`
 	JobID         string `json:"job_id,omitempty"`
 	Queue         string `json:"queue,omitempty"`
-	QueuePosition int64  `json:"queuePosition,omitempty"`
+	Lane          string `json:"lane,omitempty"`
+	Enqueued      bool   `json:"enqueued"`                // 이번 요청으로 새로 대기열에 들어갔는지
+	Duplicate     bool   `json:"duplicate,omitempty"`     // 이미 대기 중이거나 실행/완료된 Job
+	JobStatus     string `json:"jobStatus,omitempty"`     // quel_production_jobs.job_status
+	QueuePosition int64  `json:"queuePosition,omitempty"` // 같은 조직/사용자의 대기 Job 중 순서 (레인 안에서는 소유자끼리 번갈아 처리)
 }
 
 // NewEnqueueHandler - EnqueueHandler 생성
@@ -43,9 +60,17 @@ func NewEnqueueHandler() *EnqueueHandler {
 		return nil
 	}
 
+	dbClient := database.NewClient()
+	if dbClient == nil {
+		log.Println("⚠️ [Enqueue] Failed to initialize Database client")
+		return nil
+	}
+
 	log.Println("✅ [Enqueue] Handler initialized with Redis connection")
 	return &EnqueueHandler{
-		rdb: rdb,
+		rdb:      rdb,
+		dbClient: dbClient,
+		dedupTTL: cfg.JobDedupTTL,
 	}
 }
 
@@ -53,7 +78,8 @@ func NewEnqueueHandler() *EnqueueHandler {
 func (h *EnqueueHandler) RegisterRoutes(r *mux.Router) {
 	r.HandleFunc("/enqueue", h.HandleEnqueue).Methods("POST", "OPTIONS")
 	r.HandleFunc("/api/enqueue", h.HandleEnqueue).Methods("POST", "OPTIONS")
-	log.Println("✅ Enqueue routes registered: /enqueue, /api/enqueue")
+	r.HandleFunc("/api/queue/metrics", h.HandleMetrics).Methods("GET")
+	log.Println("✅ Enqueue routes registered: /enqueue, /api/enqueue, /api/queue/metrics")
 }
 
 // HandleEnqueue - POST /enqueue
@@ -91,30 +117,111 @@ func (h *EnqueueHandler) HandleEnqueue(w http.ResponseWriter, r *http.Request) {
 
 	log.Printf("📥 [Enqueue] Received job_id: %s", req.JobID)
 
-	// Redis LPUSH
+	// Job의 조직/멤버로 레인 결정
+	job, err := h.dbClient.FetchJobFromSupabase(req.JobID)
+	if errors.Is(err, database.ErrJobNotFound) {
+		log.Printf("❌ [Enqueue] Job not found: %s", req.JobID)
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(EnqueueResponse{
+			Success: false,
+			Error:   "Job not found",
+			JobID:   req.JobID,
+		})
+		return
+	}
+	if err != nil {
+		// 조회 실패는 재시도할 수 있도록 5xx (Job이 없다고 응답하지 않음)
+		log.Printf("❌ [Enqueue] Failed to fetch job %s: %v", req.JobID, err)
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(EnqueueResponse{
+			Success: false,
+			Error:   "Failed to fetch job",
+			JobID:   req.JobID,
+		})
+		return
+	}
+
+	// 이미 실행 중/완료/취소된 Job은 다시 넣지 않음 (프론트 재시도로 중복 생성·과금 방지)
+	if nonEnqueueableStatuses[job.JobStatus] {
+		log.Printf("⏭️ [Enqueue] Job %s is already %s - not enqueued", req.JobID, job.JobStatus)
+		json.NewEncoder(w).Encode(EnqueueResponse{
+			Success:   true,
+			Message:   "Job already " + job.JobStatus,
+			JobID:     req.JobID,
+			Duplicate: true,
+			JobStatus: job.JobStatus,
+		})
+		return
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	_, err := h.rdb.LPush(ctx, "jobs:queue", req.JobID).Result()
+	// 실패한 Job은 그 실패(updated_at) 기준으로 한 번만 다시 넣음 (동시 재시도 중 하나만 통과)
+	failureMarker := ""
+	if job.JobStatus == model.StatusFailed || job.JobStatus == model.StatusError {
+		failureMarker = "failed:" + job.UpdatedAt.UTC().Format(time.RFC3339Nano)
+	}
+
+	firstSeen, err := jobqueue.MarkSeen(ctx, h.rdb, req.JobID, h.dedupTTL, failureMarker)
 	if err != nil {
-		log.Printf("❌ [Enqueue] Redis LPUSH failed: %v", err)
+		log.Printf("❌ [Enqueue] Redis dedup check failed: %v", err)
 		json.NewEncoder(w).Encode(EnqueueResponse{
 			Success: false,
 			Error:   err.Error(),
 		})
 		return
 	}
+	if !firstSeen {
+		log.Printf("⏭️ [Enqueue] Job %s already enqueued (status: %s) - duplicate request", req.JobID, job.JobStatus)
+		json.NewEncoder(w).Encode(EnqueueResponse{
+			Success:   true,
+			Message:   "Job already enqueued",
+			JobID:     req.JobID,
+			Duplicate: true,
+			JobStatus: job.JobStatus,
+		})
+		return
+	}
 
-	// Queue 길이 조회
-	queueLen, _ := h.rdb.LLen(ctx, "jobs:queue").Result()
+	lane, position, err := jobqueue.Enqueue(ctx, h.rdb, job)
+	if err != nil {
+		log.Printf("❌ [Enqueue] Redis enqueue failed: %v", err)
+		// 다음 재요청이 다시 넣을 수 있도록 중복 기록 제거
+		h.rdb.Del(ctx, jobqueue.SeenKey(req.JobID))
+		json.NewEncoder(w).Encode(EnqueueResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
 
-	log.Printf("✅ [Enqueue] Job %s enqueued successfully (position: %d)", req.JobID, queueLen)
+	log.Printf("✅ [Enqueue] Job %s enqueued successfully (lane: %s, position: %d)", req.JobID, lane, position)
 
 	json.NewEncoder(w).Encode(EnqueueResponse{
 		Success:       true,
 		Message:       "Job enqueued successfully",
 		JobID:         req.JobID,
-		Queue:         "jobs:queue",
-		QueuePosition: queueLen,
+		Queue:         jobqueue.OwnersKey(lane),
+		Lane:          lane,
+		QueuePosition: position,
+		Enqueued:      true,
+		JobStatus:     job.JobStatus,
 	})
 }
+
+// HandleMetrics - GET /api/queue/metrics (레인별 대기/누적 처리 수)
+func (h *EnqueueHandler) HandleMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	metrics, err := collectQueueMetrics(ctx, h.rdb)
+	if err != nil {
+		log.Printf("❌ [Enqueue] Failed to collect queue metrics: %v", err)
+		http.Error(w, `{"error": "Failed to collect queue metrics"}`, http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(metrics)
+}
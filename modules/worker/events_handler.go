@@ -0,0 +1,173 @@
+package worker
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"quel-canvas-server/modules/common/database"
+	"quel-canvas-server/modules/common/jobevents"
+)
+
+// SSE 전송 주기
+const (
+	sseEventPollInterval = time.Second      // jobs:events:{jobId} Stream 확인 주기
+	sseKeepAliveInterval = 15 * time.Second // 프록시 idle timeout 방지용 주석 라인
+	sseStatusCheckPolls  = 10               // Stream을 이만큼 확인할 때마다 DB 상태 재확인 (summary 없이 끝난 Job 감지)
+)
+
+// StreamEvents - GET /api/jobs/{jobId}/events (Server-Sent Events)
+// Last-Event-ID(또는 ?lastEventId=)가 없으면 현재 상태(state)를 먼저 보내고 기록된 이벤트를 처음부터 재전송, summary 이벤트 후 종료
+func (h *JobStatusHandler) StreamEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, `{"error": "Streaming not supported"}`, http.StatusInternalServerError)
+		return
+	}
+
+	jobID := mux.Vars(r)["jobId"]
+	lastID := r.Header.Get("Last-Event-ID")
+	if lastID == "" {
+		lastID = r.URL.Query().Get("lastEventId")
+	}
+	if lastID != "" && !validStreamID(lastID) {
+		http.Error(w, `{"error": "Invalid Last-Event-ID"}`, http.StatusBadRequest)
+		return
+	}
+
+	job, err := h.dbClient.FetchJobFromSupabase(jobID)
+	if errors.Is(err, database.ErrJobNotFound) {
+		http.Error(w, `{"error": "Job not found"}`, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("❌ [JobEvents] Failed to fetch job %s: %v", jobID, err)
+		http.Error(w, `{"error": "Failed to fetch job"}`, http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+
+	if lastID == "" {
+		if data, err := json.Marshal(h.statusResponse(job)); err == nil {
+			writeSSE(w, "", "state", data)
+		}
+		lastID = "0"
+	}
+	flusher.Flush()
+
+	ctx := r.Context()
+	streamKey := jobevents.StreamKey(jobID)
+	finished := finishedStatuses[job.JobStatus]
+
+	poll := time.NewTicker(sseEventPollInterval)
+	defer poll.Stop()
+	lastWrite := time.Now()
+	polls := 0
+
+	for {
+		entries, err := h.rdb.XRange(ctx, streamKey, "("+lastID, "+").Result()
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("⚠️ [JobEvents] Failed to read events for job %s: %v", jobID, err)
+		}
+
+		for _, entry := range entries {
+			event, _ := entry.Values["event"].(string)
+			data, _ := entry.Values["data"].(string)
+			writeSSE(w, entry.ID, event, []byte(data))
+			lastID = entry.ID
+			lastWrite = time.Now()
+
+			if event == jobevents.EventSummary {
+				flusher.Flush()
+				return
+			}
+		}
+
+		// DB상 끝났는데 남은 기록에 summary가 없으면 (만료, panic/reaper/Kling 등 summary 없이 종료) DB 기준 요약으로 마무리
+		if finished && err == nil && len(entries) == 0 {
+			if data, err := json.Marshal(summaryFromJob(jobID, job.JobStatus, job.TotalImages, job.CompletedImages, job.GeneratedAttachIDs)); err == nil {
+				writeSSE(w, "", jobevents.EventSummary, data)
+			}
+			flusher.Flush()
+			return
+		}
+
+		// 주기적으로 DB 상태 재확인 (끝났으면 다음 확인에서 남은 기록을 읽은 뒤 요약)
+		polls++
+		if !finished && polls%sseStatusCheckPolls == 0 {
+			if latest, err := h.dbClient.FetchJobFromSupabase(jobID); err == nil {
+				job = latest
+				finished = finishedStatuses[job.JobStatus]
+			} else {
+				log.Printf("⚠️ [JobEvents] Failed to re-check job %s: %v", jobID, err)
+			}
+		}
+
+		if time.Since(lastWrite) >= sseKeepAliveInterval {
+			fmt.Fprint(w, ": keep-alive\n\n")
+			lastWrite = time.Now()
+		}
+		flusher.Flush()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-poll.C:
+		}
+	}
+}
+
+// validStreamID - Redis Stream ID 형식 (<ms> 또는 <ms>-<seq>)
+func validStreamID(id string) bool {
+	ms, seq, hasSeq := strings.Cut(id, "-")
+	if _, err := strconv.ParseUint(ms, 10, 64); err != nil {
+		return false
+	}
+	if hasSeq {
+		if _, err := strconv.ParseUint(seq, 10, 64); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// writeSSE - SSE 이벤트 한 건 출력 (id가 비어있으면 재접속 기준점으로 쓰지 않는 이벤트)
+func writeSSE(w http.ResponseWriter, id string, event string, data []byte) {
+	if id != "" {
+		fmt.Fprintf(w, "id: %s\n", id)
+	}
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data)
+}
+
+// summaryFromJob - Stream 기록이 없을 때 quel_production_jobs 행으로 summary 이벤트 구성
+func summaryFromJob(jobID string, status string, totalImages int, completedImages int, generatedAttachIDs []interface{}) jobevents.Event {
+	attachIDs := make([]int, 0, len(generatedAttachIDs))
+	for _, id := range generatedAttachIDs {
+		if floatID, ok := id.(float64); ok {
+			attachIDs = append(attachIDs, int(floatID))
+		}
+	}
+	return jobevents.Event{
+		JobID:           jobID,
+		Event:           jobevents.EventSummary,
+		Status:          status,
+		TotalImages:     totalImages,
+		CompletedImages: completedImages,
+		AttachIDs:       attachIDs,
+		Timestamp:       time.Now(),
+	}
+}
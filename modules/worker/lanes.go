@@ -0,0 +1,181 @@
+package worker
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/redis/go-redis/v9"
+
+	jobqueue "quel-canvas-server/modules/common/queue"
+)
+
+// 레인 이름/키는 Job을 직접 넣는 모듈(modify 등)과 공유 (common/queue)
+const (
+	lanePaid     = jobqueue.LanePaid
+	lanePersonal = jobqueue.LanePersonal
+	laneGuest    = jobqueue.LaneGuest
+	laneRetry    = "retry" // jobs:queue - reaper/복구로 되돌아온 Job과 레인 도입 전 방식으로 넣은 Job (항상 먼저)
+)
+
+var lanes = jobqueue.Lanes
+
+// laneSchedule - 레인별 가중치 (paid 6 : personal 3 : guest 1)
+// 차례가 된 레인이 비어있으면 우선순위 순서로 다음 레인에서 꺼내므로 낮은 레인도 굶지 않음
+var laneSchedule = []string{
+	lanePaid, lanePersonal, lanePaid, laneGuest, lanePaid,
+	lanePersonal, lanePaid, lanePersonal, lanePaid, lanePaid,
+}
+
+// 레인 Redis 키 (jobs:lane:{lane}:owners, jobs:lane:{lane}:owner:{owner})
+const (
+	laneKeyPrefix   = jobqueue.LaneKeyPrefix
+	queueMetricsKey = jobqueue.MetricsKey // {lane}:enqueued / {lane}:dequeued 누적 카운터 (경로 대기로 옮긴 Job은 dequeued를 되돌림)
+)
+
+// dequeueScript - retry 대기열 → 레인 순서대로 Job 하나를 처리 목록으로 이동
+// 레인마다 슬롯이 빈 경로의 대기 목록(먼저 꺼냈다가 경로 제한으로 기다린 Job)을 먼저, 그다음 소유자를 돌아가며
+// KEYS[1]: 처리 목록, KEYS[2]: jobs:queue, KEYS[3]: metrics
+// ARGV[1]: 레인 키 prefix, ARGV[2]: 대기 목록 키 prefix, ARGV[3]: 경로 수 n, ARGV[4..3+n]: 슬롯이 빈 경로, 나머지: 레인 순서
+var dequeueScript = redis.NewScript(`
+local job = redis.call('RPOP', KEYS[2])
+if job then
+	redis.call('LPUSH', KEYS[1], job)
+	redis.call('HINCRBY', KEYS[3], 'retry:dequeued', 1)
+	return {job, 'retry'}
+end
+local paths = tonumber(ARGV[3])
+for i = 4 + paths, #ARGV do
+	local lane = ARGV[i]
+	for p = 4, 3 + paths do
+		job = redis.call('RPOP', ARGV[2] .. ARGV[p] .. ':' .. lane)
+		if job then
+			redis.call('LPUSH', KEYS[1], job)
+			redis.call('HINCRBY', KEYS[3], lane .. ':dequeued', 1)
+			return {job, lane}
+		end
+	end
+	local owners = ARGV[1] .. lane .. ':owners'
+	local count = redis.call('LLEN', owners)
+	for j = 1, count do
+		local owner = redis.call('LMOVE', owners, owners, 'LEFT', 'RIGHT')
+		local list = ARGV[1] .. lane .. ':owner:' .. owner
+		job = redis.call('RPOP', list)
+		if redis.call('LLEN', list) == 0 then
+			redis.call('LREM', owners, -1, owner)
+		end
+		if job then
+			redis.call('LPUSH', KEYS[1], job)
+			redis.call('HINCRBY', KEYS[3], lane .. ':dequeued', 1)
+			return {job, lane}
+		end
+	end
+end
+return false
+`)
+
+// laneOrder - 이번 차례에 확인할 레인 순서 (가중치 차례 레인 먼저, 나머지는 우선순위 순)
+func laneOrder(tick int) []string {
+	preferred := laneSchedule[tick%len(laneSchedule)]
+	order := []string{preferred}
+	for _, lane := range lanes {
+		if lane != preferred {
+			order = append(order, lane)
+		}
+	}
+	return order
+}
+
+// laneMetrics - 레인별 큐 지표
+type laneMetrics struct {
+	Lane     string `json:"lane"`
+	Pending  int64  `json:"pending"`
+	Owners   int64  `json:"owners"`
+	Enqueued int64  `json:"enqueued"`
+	Dequeued int64  `json:"dequeued"`
+}
+
+// queueMetrics - 전체 큐 지표 (레인별 + retry 대기열, 경로별 대기, 처리 중)
+type queueMetrics struct {
+	Lanes    []laneMetrics    `json:"lanes"`
+	Waiting  map[string]int64 `json:"waiting"`
+	InFlight int64            `json:"inFlight"`
+}
+
+// collectQueueMetrics - Redis에서 레인별 대기 수와 누적 카운터 조회
+func collectQueueMetrics(ctx context.Context, rdb *redis.Client) (*queueMetrics, error) {
+	counters, err := rdb.HGetAll(ctx, queueMetricsKey).Result()
+	if err != nil {
+		return nil, err
+	}
+	counter := func(lane string, name string) int64 {
+		value, _ := strconv.ParseInt(counters[lane+":"+name], 10, 64)
+		return value
+	}
+
+	metrics := &queueMetrics{Waiting: make(map[string]int64)}
+
+	retryPending, err := rdb.LLen(ctx, queueKey).Result()
+	if err != nil {
+		return nil, err
+	}
+	metrics.Lanes = append(metrics.Lanes, laneMetrics{
+		Lane:     laneRetry,
+		Pending:  retryPending,
+		Dequeued: counter(laneRetry, "dequeued"),
+	})
+
+	for _, lane := range lanes {
+		owners, err := rdb.LRange(ctx, jobqueue.OwnersKey(lane), 0, -1).Result()
+		if err != nil {
+			return nil, err
+		}
+		pending := int64(0)
+		for _, owner := range owners {
+			n, err := rdb.LLen(ctx, jobqueue.OwnerKey(lane, owner)).Result()
+			if err != nil {
+				return nil, err
+			}
+			pending += n
+		}
+		metrics.Lanes = append(metrics.Lanes, laneMetrics{
+			Lane:     lane,
+			Pending:  pending,
+			Owners:   int64(len(owners)),
+			Enqueued: counter(lane, "enqueued"),
+			Dequeued: counter(lane, "dequeued"),
+		})
+	}
+
+	for _, path := range productionPaths {
+		for _, lane := range lanes {
+			n, err := rdb.LLen(ctx, waitingKey(path, lane)).Result()
+			if err != nil {
+				return nil, err
+			}
+			if n > 0 {
+				metrics.Waiting[path] += n
+			}
+		}
+	}
+
+	metrics.InFlight, err = rdb.ZCard(ctx, inflightKey).Result()
+	if err != nil {
+		return nil, err
+	}
+	return metrics, nil
+}
+
+// laneLists - 레인에 대기 중인 모든 소유자 목록 키 (복구 시 중복 확인용)
+func laneLists(ctx context.Context, rdb *redis.Client) ([]string, error) {
+	var lists []string
+	for _, lane := range lanes {
+		owners, err := rdb.LRange(ctx, jobqueue.OwnersKey(lane), 0, -1).Result()
+		if err != nil {
+			return nil, err
+		}
+		for _, owner := range owners {
+			lists = append(lists, jobqueue.OwnerKey(lane, owner))
+		}
+	}
+	return lists, nil
+}
@@ -0,0 +1,68 @@
+package worker
+
+import (
+	"reflect"
+	"testing"
+
+	"quel-canvas-server/modules/common/model"
+	jobqueue "quel-canvas-server/modules/common/queue"
+)
+
+func TestLaneOrder(t *testing.T) {
+	cases := []struct {
+		tick int
+		want []string
+	}{
+		{tick: 0, want: []string{lanePaid, lanePersonal, laneGuest}},
+		{tick: 1, want: []string{lanePersonal, lanePaid, laneGuest}},
+		{tick: 3, want: []string{laneGuest, lanePaid, lanePersonal}},
+		{tick: 13, want: []string{laneGuest, lanePaid, lanePersonal}}, // 스케줄 한 바퀴 뒤
+	}
+
+	for _, tc := range cases {
+		if got := laneOrder(tc.tick); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("laneOrder(%d) = %v, want %v", tc.tick, got, tc.want)
+		}
+	}
+}
+
+func TestLaneScheduleWeights(t *testing.T) {
+	// 한 바퀴 동안 차례가 되는 레인 수가 가중치 (paid 6 : personal 3 : guest 1)
+	counts := make(map[string]int)
+	for tick := range laneSchedule {
+		counts[laneOrder(tick)[0]]++
+	}
+	want := map[string]int{lanePaid: 6, lanePersonal: 3, laneGuest: 1}
+	if !reflect.DeepEqual(counts, want) {
+		t.Fatalf("lane weights = %v, want %v", counts, want)
+	}
+}
+
+func TestJobLane(t *testing.T) {
+	org := "org-1"
+	member := "member-1"
+	empty := ""
+
+	cases := []struct {
+		name      string
+		job       model.ProductionJob
+		wantLane  string
+		wantOwner string
+	}{
+		{name: "org job", job: model.ProductionJob{OrgID: &org, QuelMemberID: &member}, wantLane: lanePaid, wantOwner: "org:org-1"},
+		{name: "member job", job: model.ProductionJob{QuelMemberID: &member}, wantLane: lanePersonal, wantOwner: "member:member-1"},
+		{name: "empty org falls back to member", job: model.ProductionJob{OrgID: &empty, QuelMemberID: &member}, wantLane: lanePersonal, wantOwner: "member:member-1"},
+		{name: "landing with member", job: model.ProductionJob{QuelProductionPath: "landing", OrgID: &org, QuelMemberID: &member}, wantLane: laneGuest, wantOwner: "member:member-1"},
+		{name: "landing anonymous", job: model.ProductionJob{QuelProductionPath: "landing"}, wantLane: laneGuest, wantOwner: "anonymous"},
+		{name: "no owner", job: model.ProductionJob{}, wantLane: laneGuest, wantOwner: "anonymous"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			lane, owner := jobqueue.JobLane(&tc.job)
+			if lane != tc.wantLane || owner != tc.wantOwner {
+				t.Fatalf("JobLane = (%s, %s), want (%s, %s)", lane, owner, tc.wantLane, tc.wantOwner)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package worker
+
+import (
+	"context"
+	"log"
+
+	"quel-canvas-server/modules/common/model"
+)
+
+// productionPaths - 워커가 라우팅하는 처리 경로 (modify는 maskDataUrl로 식별)
+var productionPaths = []string{"fashion", "beauty", "eats", "cinema", "cartoon", "multiview", "landing", "modify"}
+
+// productionPath - Job이 실행될 처리 경로 (동시 처리 제한과 라우팅에 공통 사용)
+func productionPath(job *model.ProductionJob) string {
+	// job_type이 "modify"이거나 job_input_data에 maskDataUrl이 있으면 modify
+	// (DB 제약으로 인해 job_type은 simple_general로 저장되지만 maskDataUrl로 modify job 식별)
+	if job.JobType == "modify" {
+		return "modify"
+	}
+	if job.JobInputData != nil {
+		if _, hasMask := job.JobInputData["maskDataUrl"]; hasMask {
+			return "modify"
+		}
+	}
+
+	// NULL, 빈 문자열, 알 수 없는 경로는 fashion으로 처리
+	for _, path := range productionPaths {
+		if job.QuelProductionPath == path {
+			return path
+		}
+	}
+	if job.QuelProductionPath != "" {
+		log.Printf("⚠️  Unknown quel_production_path: %s, using Fashion as default", job.QuelProductionPath)
+	}
+	return "fashion"
+}
+
+// workerPool - 전체/경로별 동시 처리 슬롯 (슬롯이 없으면 Job을 Redis에서 꺼내지 않음)
+type workerPool struct {
+	global chan struct{}
+	paths  map[string]chan struct{} // 제한이 설정된 경로만
+}
+
+func newWorkerPool(maxConcurrency int, pathConcurrency map[string]int) *workerPool {
+	pool := &workerPool{
+		global: make(chan struct{}, maxConcurrency),
+		paths:  make(map[string]chan struct{}),
+	}
+	for path, limit := range pathConcurrency {
+		pool.paths[path] = make(chan struct{}, limit)
+	}
+	return pool
+}
+
+// acquire - 전체 슬롯이 빌 때까지 대기
+func (p *workerPool) acquire(ctx context.Context) bool {
+	select {
+	case p.global <- struct{}{}:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func (p *workerPool) release() {
+	<-p.global
+}
+
+// tryAcquirePath - 경로 슬롯 확보 (제한 없는 경로는 항상 성공)
+func (p *workerPool) tryAcquirePath(path string) bool {
+	slots, limited := p.paths[path]
+	if !limited {
+		return true
+	}
+	select {
+	case slots <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+func (p *workerPool) releasePath(path string) {
+	if slots, limited := p.paths[path]; limited {
+		<-slots
+	}
+}
+
+// availablePaths - 제한이 있고 지금 슬롯이 남은 경로 (대기 목록을 먼저 확인할 대상)
+func (p *workerPool) availablePaths() []string {
+	var available []string
+	for _, path := range productionPaths {
+		if slots, limited := p.paths[path]; limited && len(slots) < cap(slots) {
+			available = append(available, path)
+		}
+	}
+	return available
+}
@@ -0,0 +1,515 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+
+	"quel-canvas-server/modules/common/config"
+	"quel-canvas-server/modules/common/database"
+	"quel-canvas-server/modules/common/jobevents"
+	"quel-canvas-server/modules/common/model"
+	redisClient "quel-canvas-server/modules/common/redis"
+)
+
+// Reliable queue Redis 키
+// 대기: 레인별 소유자 목록 (lanes.go), jobs:queue는 reaper/복구로 되돌아온 Job (LPUSH로 추가, 오른쪽에서 꺼냄)
+// 처리 중: jobs:processing:{workerId} 로 원자적으로 이동 후 jobs:inflight(ZSET, visibility 만료 시각)에 등록
+const (
+	queueKey              = "jobs:queue"
+	processingKeyPrefix   = "jobs:processing:"
+	waitingKeyPrefix      = "jobs:waiting:"       // 경로 동시 처리 제한으로 대기 중인 Job (jobs:waiting:{path}:{lane})
+	inflightKey           = "jobs:inflight"       // jobID → visibility 만료 시각 (unix ms)
+	inflightOwnerKey      = "jobs:inflight:owner" // jobID → workerId
+	workersKey            = "jobs:workers"        // 등록된 워커 ID
+	workerHeartbeatPrefix = "jobs:worker:"        // 워커 생존 신호 (TTL = visibility timeout)
+	recoveryLockKey       = "jobs:recovery:lock"  // 시작 시 복구는 한 인스턴스만
+	reclaimedOwner        = "reclaimed"           // 취소 알림 후 재전달을 기다리는 Job의 owner 표시
+
+	queuePollInterval = 500 * time.Millisecond
+	queueOpTimeout    = 5 * time.Second
+	recoveryLockTTL   = 5 * time.Minute
+)
+
+// requeueScript - visibility가 만료된 Job을 처리 목록에서 빼서 대기열 맨 앞으로 (ARGV[3]이 비어있으면 만료 확인 없이)
+var requeueScript = redis.NewScript(`
+local owner = redis.call('HGET', KEYS[2], ARGV[1])
+if ARGV[3] ~= '' then
+	local score = redis.call('ZSCORE', KEYS[1], ARGV[1])
+	if not score or tonumber(score) > tonumber(ARGV[3]) then
+		return 0
+	end
+end
+redis.call('ZREM', KEYS[1], ARGV[1])
+redis.call('HDEL', KEYS[2], ARGV[1])
+if owner then
+	redis.call('LREM', ARGV[2] .. owner, 1, ARGV[1])
+end
+redis.call('RPUSH', KEYS[3], ARGV[1])
+return 1
+`)
+
+// reclaimScript - owner 워커가 살아있는데 visibility가 만료된 Job을 회수 표시 (owner를 reclaimedOwner로 바꾸고 처리 목록에서 제거)
+// 원래 실행의 ack/heartbeat는 owner가 달라 더 이상 영향이 없고, 다음 reap에서 대기열로 되돌림
+// ARGV[1]: jobID, ARGV[2]: 처리 목록 prefix, ARGV[3]: 현재 시각, ARGV[4]: owner, ARGV[5]: 다시 만료될 시각, ARGV[6]: 회수 표시
+var reclaimScript = redis.NewScript(`
+local score = redis.call('ZSCORE', KEYS[1], ARGV[1])
+if not score or tonumber(score) > tonumber(ARGV[3]) then
+	return 0
+end
+if redis.call('HGET', KEYS[2], ARGV[1]) ~= ARGV[4] then
+	return 0
+end
+redis.call('ZADD', KEYS[1], 'XX', ARGV[5], ARGV[1])
+redis.call('HSET', KEYS[2], ARGV[1], ARGV[6])
+redis.call('LREM', ARGV[2] .. ARGV[4], 1, ARGV[1])
+return 1
+`)
+
+// requeueWorkerScript - 종료된 워커의 처리 목록 전체를 대기열 맨 앞으로 (먼저 꺼낸 Job이 먼저 처리되도록)
+var requeueWorkerScript = redis.NewScript(`
+local moved = 0
+while true do
+	local job = redis.call('LMOVE', KEYS[1], KEYS[2], 'LEFT', 'RIGHT')
+	if not job then
+		break
+	end
+	redis.call('ZREM', KEYS[3], job)
+	redis.call('HDEL', KEYS[4], job)
+	moved = moved + 1
+end
+return moved
+`)
+
+// releaseScript - 이 워커가 아직 소유한 Job만 visibility 추적에서 제거 (ARGV[3]이 있으면 그 대기 목록으로)
+// 자기 처리 목록에서는 항상 제거, reaper가 회수해 다른 워커가 가져간 Job은 건드리지 않고 0 반환
+var releaseScript = redis.NewScript(`
+redis.call('LREM', KEYS[1], 1, ARGV[1])
+if redis.call('HGET', KEYS[3], ARGV[1]) ~= ARGV[2] then
+	return 0
+end
+redis.call('ZREM', KEYS[2], ARGV[1])
+redis.call('HDEL', KEYS[3], ARGV[1])
+if ARGV[3] ~= '' then
+	redis.call('LPUSH', ARGV[3], ARGV[1])
+end
+return 1
+`)
+
+// extendScript - 이 워커가 소유한 Job의 visibility만 연장 (ARGV[3..]: jobID)
+var extendScript = redis.NewScript(`
+for i = 3, #ARGV do
+	if redis.call('HGET', KEYS[2], ARGV[i]) == ARGV[2] then
+		redis.call('ZADD', KEYS[1], 'XX', ARGV[1], ARGV[i])
+	end
+end
+return 0
+`)
+
+// reliableQueue - 처리 중 Job을 추적해 워커가 죽어도 Job을 잃지 않는 큐
+type reliableQueue struct {
+	rdb        *redis.Client
+	workerID   string
+	visibility time.Duration
+	heartbeat  time.Duration
+
+	mutex    sync.Mutex
+	inflight map[string]time.Time // 이 워커가 처리 중인 Job → 시작 시각
+	tick     int                  // 레인 가중치 차례
+}
+
+func newReliableQueue(rdb *redis.Client, cfg *config.Config) *reliableQueue {
+	workerID := cfg.InstanceID
+	if workerID == "" {
+		hostname, _ := os.Hostname()
+		workerID = hostname + "-" + uuid.NewString()[:8]
+	}
+
+	return &reliableQueue{
+		rdb:        rdb,
+		workerID:   workerID,
+		visibility: cfg.JobVisibilityTimeout,
+		heartbeat:  cfg.JobHeartbeatInterval,
+		inflight:   make(map[string]time.Time),
+	}
+}
+
+func processingKey(workerID string) string {
+	return processingKeyPrefix + workerID
+}
+
+func waitingKey(path string, lane string) string {
+	return waitingKeyPrefix + path + ":" + lane
+}
+
+func workerHeartbeatKey(workerID string) string {
+	return workerHeartbeatPrefix + workerID
+}
+
+// next - 레인 순서에 따라 Job 하나를 처리 목록으로 옮김 (대기 중 Job이 없으면 잠시 기다린 뒤 빈 문자열)
+// paths: 슬롯이 남은 경로 - 각 레인 차례에 그 경로의 대기 목록을 소유자 대기열보다 먼저 확인
+func (q *reliableQueue) next(ctx context.Context, paths []string) (string, string, error) {
+	q.mutex.Lock()
+	order := laneOrder(q.tick)
+	q.tick++
+	q.mutex.Unlock()
+
+	args := []interface{}{laneKeyPrefix, waitingKeyPrefix, len(paths)}
+	for _, path := range paths {
+		args = append(args, path)
+	}
+	for _, lane := range order {
+		args = append(args, lane)
+	}
+	result, err := dequeueScript.Run(ctx, q.rdb,
+		[]string{processingKey(q.workerID), queueKey, queueMetricsKey}, args...).StringSlice()
+	if err == redis.Nil {
+		select {
+		case <-ctx.Done():
+		case <-time.After(queuePollInterval):
+		}
+		return "", "", nil
+	}
+	if err != nil {
+		return "", "", err
+	}
+
+	jobID, lane := result[0], result[1]
+	q.claim(jobID)
+	return jobID, lane, nil
+}
+
+// claim - 처리 목록으로 옮긴 Job을 visibility 추적에 등록
+func (q *reliableQueue) claim(jobID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), queueOpTimeout)
+	defer cancel()
+
+	pipe := q.rdb.TxPipeline()
+	pipe.ZAdd(ctx, inflightKey, redis.Z{Score: float64(q.deadline().UnixMilli()), Member: jobID})
+	pipe.HSet(ctx, inflightOwnerKey, jobID, q.workerID)
+	if _, err := pipe.Exec(ctx); err != nil {
+		// 처리 목록에는 있으므로 워커가 죽으면 reaper가 복구
+		log.Printf("⚠️ [Queue] Failed to register in-flight job %s: %v", jobID, err)
+	}
+
+	q.mutex.Lock()
+	q.inflight[jobID] = time.Now()
+	q.mutex.Unlock()
+}
+
+// park - 경로 슬롯이 없는 Job을 처리 목록에서 경로/레인별 대기 목록으로 옮김 (메모리에 들고 있지 않음)
+// 꺼낸 레인(fromLane)의 dequeued는 되돌리고, 대기 목록에서 다시 꺼낼 때 waitLane으로 셈
+func (q *reliableQueue) park(jobID string, path string, fromLane string, lane string) {
+	q.mutex.Lock()
+	delete(q.inflight, jobID)
+	q.mutex.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), queueOpTimeout)
+	defer cancel()
+
+	released, err := q.release(ctx, jobID, waitingKey(path, lane))
+	if err != nil {
+		log.Printf("❌ [Queue] Failed to park job %s on %s: %v", jobID, path, err)
+	} else if !released {
+		log.Printf("⚠️ [Queue] Job %s was reassigned to another worker, not parking", jobID)
+	} else {
+		q.rdb.HIncrBy(ctx, queueMetricsKey, fromLane+":dequeued", -1)
+	}
+}
+
+// ack - 처리가 끝난 Job을 처리 목록에서 제거 (성공/실패 무관, 실패 재시도는 파이프라인이 상태로 기록)
+func (q *reliableQueue) ack(jobID string) {
+	q.mutex.Lock()
+	delete(q.inflight, jobID)
+	q.mutex.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), queueOpTimeout)
+	defer cancel()
+
+	released, err := q.release(ctx, jobID, "")
+	if err != nil {
+		log.Printf("❌ [Queue] Failed to ack job %s: %v", jobID, err)
+	} else if !released {
+		// visibility 만료로 회수된 뒤 다른 워커가 가져간 Job - 그 워커의 추적 정보는 유지
+		log.Printf("⚠️ [Queue] Job %s was reassigned to another worker before ack", jobID)
+	}
+}
+
+// release - 처리 목록에서 제거하고, 아직 이 워커 소유면 visibility 추적에서도 제거
+func (q *reliableQueue) release(ctx context.Context, jobID string, parkKey string) (bool, error) {
+	released, err := releaseScript.Run(ctx, q.rdb,
+		[]string{processingKey(q.workerID), inflightKey, inflightOwnerKey}, jobID, q.workerID, parkKey).Int()
+	return released == 1, err
+}
+
+func (q *reliableQueue) deadline() time.Time {
+	return time.Now().Add(q.visibility)
+}
+
+// heartbeatLoop - 워커 생존 신호와 처리 중 Job의 visibility 연장
+func (q *reliableQueue) heartbeatLoop(ctx context.Context) {
+	ticker := time.NewTicker(q.heartbeat)
+	defer ticker.Stop()
+
+	q.beat()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			q.beat()
+		}
+	}
+}
+
+func (q *reliableQueue) beat() {
+	ctx, cancel := context.WithTimeout(context.Background(), queueOpTimeout)
+	defer cancel()
+
+	q.mutex.Lock()
+	jobIDs := make([]string, 0, len(q.inflight))
+	for jobID := range q.inflight {
+		jobIDs = append(jobIDs, jobID)
+	}
+	q.mutex.Unlock()
+
+	pipe := q.rdb.TxPipeline()
+	pipe.Set(ctx, workerHeartbeatKey(q.workerID), time.Now().Unix(), q.visibility)
+	pipe.SAdd(ctx, workersKey, q.workerID)
+	if len(jobIDs) > 0 {
+		// reaper가 이미 회수했거나 다른 워커가 가져간 Job은 연장하지 않음
+		args := []interface{}{q.deadline().UnixMilli(), q.workerID}
+		for _, jobID := range jobIDs {
+			args = append(args, jobID)
+		}
+		extendScript.Eval(ctx, pipe, []string{inflightKey, inflightOwnerKey}, args...)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Printf("⚠️ [Queue] Heartbeat failed for worker %s: %v", q.workerID, err)
+	}
+}
+
+// reapLoop - visibility가 만료된 Job과 종료된 워커의 처리 목록을 주기적으로 대기열에 되돌림
+func (q *reliableQueue) reapLoop(ctx context.Context) {
+	ticker := time.NewTicker(2 * q.heartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			q.reapExpired()
+			q.reapDeadWorkers()
+		}
+	}
+}
+
+// reapExpired - visibility가 만료된 Job 처리
+// owner 워커의 생존 신호가 끊겼으면 (reapDeadWorkers와 같은 기준) 바로 대기열 맨 앞으로,
+// 살아있으면 아직 실행 중일 수 있으므로 jobs:cancel로 원래 실행을 멈추고 다음 reap에서 다시 넣음
+func (q *reliableQueue) reapExpired() {
+	ctx, cancel := context.WithTimeout(context.Background(), queueOpTimeout)
+	defer cancel()
+
+	now := time.Now().UnixMilli()
+	expired, err := q.rdb.ZRangeByScore(ctx, inflightKey, &redis.ZRangeBy{
+		Min: "-inf",
+		Max: fmt.Sprintf("%d", now),
+	}).Result()
+	if err != nil {
+		log.Printf("❌ [Queue] Failed to scan in-flight jobs: %v", err)
+		return
+	}
+
+	for _, jobID := range expired {
+		owner, err := q.rdb.HGet(ctx, inflightOwnerKey, jobID).Result()
+		if err != nil && err != redis.Nil {
+			log.Printf("❌ [Queue] Failed to read owner of expired job %s: %v", jobID, err)
+			continue
+		}
+		if owner != "" && owner != reclaimedOwner {
+			alive, err := q.rdb.Exists(ctx, workerHeartbeatKey(owner)).Result()
+			if err != nil {
+				log.Printf("❌ [Queue] Failed to check heartbeat of worker %s: %v", owner, err)
+				continue
+			}
+			if alive > 0 {
+				q.reclaim(ctx, jobID, owner, now)
+				continue
+			}
+		}
+
+		moved, err := requeueScript.Run(ctx, q.rdb,
+			[]string{inflightKey, inflightOwnerKey, queueKey},
+			jobID, processingKeyPrefix, now).Int()
+		if err != nil {
+			log.Printf("❌ [Queue] Failed to requeue expired job %s: %v", jobID, err)
+			continue
+		}
+		if moved == 1 {
+			log.Printf("♻️ [Queue] Visibility timeout expired - requeued job %s", jobID)
+		}
+	}
+}
+
+// reclaim - 살아있는 워커가 연장하지 않은 Job의 원래 실행을 멈추고 다음 reap 때 재전달
+// 취소 알림이 재전달된 실행에 닿지 않도록 대기열에는 한 reap 주기 뒤에 넣음
+func (q *reliableQueue) reclaim(ctx context.Context, jobID string, owner string, now int64) {
+	requeueAt := now + (2 * q.heartbeat).Milliseconds()
+	reclaimed, err := reclaimScript.Run(ctx, q.rdb,
+		[]string{inflightKey, inflightOwnerKey},
+		jobID, processingKeyPrefix, now, owner, requeueAt, reclaimedOwner).Int()
+	if err != nil {
+		log.Printf("❌ [Queue] Failed to reclaim expired job %s from worker %s: %v", jobID, owner, err)
+		return
+	}
+	if reclaimed == 0 {
+		return
+	}
+
+	if err := redisClient.PublishJobCancelled(q.rdb, jobID); err != nil {
+		log.Printf("⚠️ [Queue] Failed to publish cancel for reclaimed job %s: %v", jobID, err)
+	}
+	log.Printf("♻️ [Queue] Job %s expired on live worker %s - cancelled original run, requeueing on next reap", jobID, owner)
+}
+
+// reapDeadWorkers - 생존 신호가 끊긴 워커의 처리 목록 회수
+func (q *reliableQueue) reapDeadWorkers() {
+	ctx, cancel := context.WithTimeout(context.Background(), queueOpTimeout)
+	defer cancel()
+
+	workers, err := q.rdb.SMembers(ctx, workersKey).Result()
+	if err != nil {
+		log.Printf("❌ [Queue] Failed to list workers: %v", err)
+		return
+	}
+
+	for _, workerID := range workers {
+		if workerID == q.workerID {
+			continue
+		}
+		alive, err := q.rdb.Exists(ctx, workerHeartbeatKey(workerID)).Result()
+		if err != nil || alive > 0 {
+			continue
+		}
+
+		moved, err := q.requeueWorker(ctx, workerID)
+		if err != nil {
+			log.Printf("❌ [Queue] Failed to recover jobs of dead worker %s: %v", workerID, err)
+			continue
+		}
+		q.rdb.SRem(ctx, workersKey, workerID)
+		if moved > 0 {
+			log.Printf("♻️ [Queue] Worker %s stopped heartbeating - requeued %d jobs", workerID, moved)
+		}
+	}
+}
+
+func (q *reliableQueue) requeueWorker(ctx context.Context, workerID string) (int, error) {
+	return requeueWorkerScript.Run(ctx, q.rdb,
+		[]string{processingKey(workerID), queueKey, inflightKey, inflightOwnerKey}).Int()
+}
+
+// isTracked - 레인/retry 대기열, 경로별 대기 목록, 처리 중 목록 어디에든 있는지 (복구 시 중복 추가 방지)
+func (q *reliableQueue) isTracked(ctx context.Context, jobID string) (bool, error) {
+	if _, err := q.rdb.ZScore(ctx, inflightKey, jobID).Result(); err == nil {
+		return true, nil
+	} else if err != redis.Nil {
+		return false, err
+	}
+
+	lists, err := laneLists(ctx, q.rdb)
+	if err != nil {
+		return false, err
+	}
+	lists = append(lists, queueKey)
+	for _, path := range productionPaths {
+		for _, lane := range lanes {
+			lists = append(lists, waitingKey(path, lane))
+		}
+	}
+	workers, err := q.rdb.SMembers(ctx, workersKey).Result()
+	if err != nil {
+		return false, err
+	}
+	for _, workerID := range workers {
+		lists = append(lists, processingKey(workerID))
+	}
+	for _, list := range lists {
+		if _, err := q.rdb.LPos(ctx, list, jobID, redis.LPosArgs{}).Result(); err == nil {
+			return true, nil
+		} else if err != redis.Nil {
+			return false, err
+		}
+	}
+	return false, nil
+}
+
+// recoverOnStartup - 이전 실행에서 남은 처리 목록과 processing으로 멈춘 Job 복구
+func (q *reliableQueue) recoverOnStartup(dbClient *database.Client, window time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	// 같은 워커 ID로 재시작한 경우 (INSTANCE_ID 고정) 바로 회수
+	if moved, err := q.requeueWorker(ctx, q.workerID); err != nil {
+		log.Printf("❌ [Queue] Failed to recover own processing list: %v", err)
+	} else if moved > 0 {
+		log.Printf("♻️ [Queue] Requeued %d jobs left by previous run of %s", moved, q.workerID)
+	}
+
+	// Supabase 상태 복구는 여러 인스턴스가 동시에 시작해도 한 번만
+	locked, err := q.rdb.SetNX(ctx, recoveryLockKey, q.workerID, recoveryLockTTL).Result()
+	if err != nil || !locked {
+		return
+	}
+
+	jobs, err := dbClient.FetchJobsByStatus(model.StatusProcessing)
+	if err != nil {
+		log.Printf("❌ [Queue] Failed to load processing jobs for recovery: %v", err)
+		return
+	}
+
+	requeued, failed := 0, 0
+	for _, job := range jobs {
+		tracked, err := q.isTracked(ctx, job.JobID)
+		if err != nil {
+			log.Printf("⚠️ [Queue] Skipping recovery of %s: %v", job.JobID, err)
+			continue
+		}
+		if tracked {
+			// 살아있는 워커가 처리 중이거나 reaper가 회수할 Job
+			continue
+		}
+
+		if time.Since(job.UpdatedAt) > window {
+			// 처리 중인 워커가 없으므로 DB 행 기준으로 failed/summary 이벤트 (SSE 종료, 웹훅)
+			forget := jobevents.TrackUntracked(&job)
+			if err := dbClient.UpdateJobFailed(ctx, job.JobID, "worker lost while processing"); err == nil {
+				failed++
+			}
+			forget()
+			continue
+		}
+
+		if err := dbClient.UpdateJobStatus(ctx, job.JobID, model.StatusPending); err != nil {
+			log.Printf("⚠️ [Queue] Failed to reset job %s to pending: %v", job.JobID, err)
+			continue
+		}
+		if err := q.rdb.RPush(ctx, queueKey, job.JobID).Err(); err != nil {
+			log.Printf("❌ [Queue] Failed to requeue stuck job %s: %v", job.JobID, err)
+			continue
+		}
+		requeued++
+	}
+
+	if requeued > 0 || failed > 0 {
+		log.Printf("♻️ [Queue] Startup recovery: requeued %d stuck jobs, marked %d stale jobs failed", requeued, failed)
+	}
+}
@@ -0,0 +1,203 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	jobqueue "quel-canvas-server/modules/common/queue"
+)
+
+// 예상 시작 시각 계산용 처리 시간 (jobs:metrics의 duration:avg_ms, 최근 Job 비중 10% 지수 평균)
+const durationAvgField = "duration:avg_ms"
+
+var durationScript = redis.NewScript(`
+local avg = tonumber(redis.call('HGET', KEYS[1], ARGV[2]) or ARGV[1])
+avg = avg * 0.9 + tonumber(ARGV[1]) * 0.1
+redis.call('HSET', KEYS[1], ARGV[2], math.floor(avg))
+return math.floor(avg)
+`)
+
+// laneWeights - laneSchedule에서 레인별 차례 수
+func laneWeights() map[string]int {
+	weights := make(map[string]int)
+	for _, lane := range laneSchedule {
+		weights[lane]++
+	}
+	return weights
+}
+
+// recordDuration - 끝난 Job의 처리 시간을 평균에 반영
+func (q *reliableQueue) recordDuration(duration time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), queueOpTimeout)
+	defer cancel()
+	durationScript.Run(ctx, q.rdb, []string{queueMetricsKey}, duration.Milliseconds(), durationAvgField)
+}
+
+// queuePosition - Redis 큐 기준 Job 위치
+type queuePosition struct {
+	State            string     `json:"state"` // queued / waiting / running / not_queued
+	Lane             string     `json:"lane,omitempty"`
+	Position         int64      `json:"position,omitempty"`  // 1이면 다음 차례
+	JobsAhead        int64      `json:"jobsAhead,omitempty"` // 앞에 있는 대기 Job 수 (레인 가중치/소유자 라운드로빈 반영 추정)
+	WorkerID         string     `json:"workerId,omitempty"`
+	Detail           string     `json:"detail"` // 사람이 읽는 요약 ("running on worker X" 등)
+	EstimatedStartAt *time.Time `json:"estimatedStartAt,omitempty"`
+}
+
+// locateJob - 처리 중/대기 중인 큐에서 Job 위치와 예상 시작 시각 계산
+func locateJob(ctx context.Context, rdb *redis.Client, jobID string, concurrency int) (*queuePosition, error) {
+	// 처리 중
+	if workerID, err := rdb.HGet(ctx, inflightOwnerKey, jobID).Result(); err == nil {
+		if workerID == reclaimedOwner {
+			return &queuePosition{State: "queued", Lane: laneRetry, Detail: "reclaimed after visibility timeout, requeueing"}, nil
+		}
+		return &queuePosition{State: "running", WorkerID: workerID, Detail: "running on worker " + workerID}, nil
+	} else if err != redis.Nil {
+		return nil, err
+	}
+
+	retryLen, err := rdb.LLen(ctx, queueKey).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	// retry 대기열 (항상 먼저 처리, 오른쪽에서 꺼냄)
+	if index, err := rdb.LPos(ctx, queueKey, jobID, redis.LPosArgs{}).Result(); err == nil {
+		ahead := retryLen - index - 1
+		return withEstimate(ctx, rdb, &queuePosition{State: "queued", Lane: laneRetry, Position: ahead + 1, JobsAhead: ahead}, concurrency)
+	} else if err != redis.Nil {
+		return nil, err
+	}
+
+	// 경로 동시 처리 제한으로 대기 중 (해당 경로 슬롯이 비면 레인 차례에 소유자 대기열보다 먼저 처리)
+	for _, path := range productionPaths {
+		for _, lane := range lanes {
+			key := waitingKey(path, lane)
+			index, err := rdb.LPos(ctx, key, jobID, redis.LPosArgs{}).Result()
+			if err == redis.Nil {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			length, err := rdb.LLen(ctx, key).Result()
+			if err != nil {
+				return nil, err
+			}
+			ahead := length - index - 1
+			return withEstimate(ctx, rdb, &queuePosition{State: "waiting", Lane: lane, Position: ahead + 1, JobsAhead: ahead}, concurrency)
+		}
+	}
+
+	// 레인/소유자 대기열
+	pending := make(map[string]int64) // 레인별 전체 대기 수
+	var found *queuePosition
+	var laneAhead int64
+	for _, lane := range lanes {
+		owners, err := rdb.LRange(ctx, jobqueue.OwnersKey(lane), 0, -1).Result()
+		if err != nil {
+			return nil, err
+		}
+
+		lengths := make([]int64, len(owners))
+		ownerIndex := -1
+		var rank int64 // 소유자 목록에서 꺼낼 순서 (1부터)
+		for i, owner := range owners {
+			key := jobqueue.OwnerKey(lane, owner)
+			lengths[i], err = rdb.LLen(ctx, key).Result()
+			if err != nil {
+				return nil, err
+			}
+			pending[lane] += lengths[i]
+
+			if found == nil {
+				index, err := rdb.LPos(ctx, key, jobID, redis.LPosArgs{}).Result()
+				if err == nil {
+					ownerIndex = i
+					rank = lengths[i] - index
+				} else if err != redis.Nil {
+					return nil, err
+				}
+			}
+		}
+		if ownerIndex < 0 {
+			continue
+		}
+
+		// 라운드로빈: 목록 앞쪽 소유자는 rank번, 뒤쪽 소유자는 rank-1번 먼저 꺼내짐
+		laneAhead = rank - 1
+		for i, length := range lengths {
+			if i == ownerIndex {
+				continue
+			}
+			turns := rank - 1
+			if i < ownerIndex {
+				turns = rank
+			}
+			laneAhead += min(length, turns)
+		}
+		found = &queuePosition{State: "queued", Lane: lane}
+	}
+	if found == nil {
+		return &queuePosition{State: "not_queued", Detail: "not in queue"}, nil
+	}
+
+	// 다른 레인은 가중치 비율만큼 사이사이 처리됨
+	weights := laneWeights()
+	ahead := retryLen + laneAhead
+	for _, lane := range lanes {
+		if lane == found.Lane {
+			continue
+		}
+		share := (laneAhead + 1) * int64(weights[lane]) / int64(weights[found.Lane])
+		ahead += min(pending[lane], share)
+	}
+	found.JobsAhead = ahead
+	found.Position = ahead + 1
+	return withEstimate(ctx, rdb, found, concurrency)
+}
+
+// withEstimate - 평균 처리 시간과 살아있는 워커 수로 예상 시작 시각 계산 (처리 기록이 없으면 생략)
+func withEstimate(ctx context.Context, rdb *redis.Client, position *queuePosition, concurrency int) (*queuePosition, error) {
+	position.Detail = fmt.Sprintf("position %d in %s queue", position.Position, position.Lane)
+
+	avgMs, err := rdb.HGet(ctx, queueMetricsKey, durationAvgField).Int64()
+	if err == redis.Nil {
+		return position, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if avgMs <= 0 {
+		return position, nil
+	}
+
+	workers, err := rdb.SMembers(ctx, workersKey).Result()
+	if err != nil {
+		return nil, err
+	}
+	alive := 0
+	for _, workerID := range workers {
+		if n, err := rdb.Exists(ctx, workerHeartbeatKey(workerID)).Result(); err == nil && n > 0 {
+			alive++
+		}
+	}
+	if alive == 0 {
+		return position, nil
+	}
+
+	inflight, err := rdb.ZCard(ctx, inflightKey).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	// 앞의 대기 Job + 처리 중 Job이 전체 슬롯을 몇 바퀴 채우는지
+	capacity := int64(alive * concurrency)
+	waves := (position.JobsAhead + inflight) / capacity
+	estimated := time.Now().Add(time.Duration(waves*avgMs) * time.Millisecond)
+	position.EstimatedStartAt = &estimated
+	return position, nil
+}
@@ -0,0 +1,146 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/redis/go-redis/v9"
+	"quel-canvas-server/modules/common/config"
+	"quel-canvas-server/modules/common/database"
+	"quel-canvas-server/modules/common/model"
+)
+
+// JobStatusHandler - 모든 모듈 공통 Job 상태/대기 순서 조회 API
+type JobStatusHandler struct {
+	rdb         *redis.Client
+	dbClient    *database.Client
+	concurrency int // 워커당 동시 처리 수 (예상 시작 시각 계산용)
+}
+
+// JobStatusResponse - GET /api/jobs/{jobId} 응답
+type JobStatusResponse struct {
+	JobID              string         `json:"jobId"`
+	Status             string         `json:"status"`
+	JobType            string         `json:"jobType"`
+	ProductionPath     string         `json:"productionPath"`
+	ProductionID       *string        `json:"productionId"`
+	TotalImages        int            `json:"totalImages"`
+	CompletedImages    int            `json:"completedImages"`
+	FailedImages       int            `json:"failedImages"`
+	Progress           float64        `json:"progress"` // 0~100
+	GeneratedAttachIDs []interface{}  `json:"generatedAttachIds"`
+	ErrorMessage       *string        `json:"errorMessage"`
+	RetryCount         int            `json:"retryCount"`
+	CreatedAt          time.Time      `json:"createdAt"`
+	StartedAt          *time.Time     `json:"startedAt"`
+	CompletedAt        *time.Time     `json:"completedAt"`
+	UpdatedAt          time.Time      `json:"updatedAt"`
+	Queue              *queuePosition `json:"queue,omitempty"` // 끝난 Job은 생략
+}
+
+// 대기열을 확인할 필요 없는 상태
+var finishedStatuses = map[string]bool{
+	model.StatusCompleted:     true,
+	model.StatusFailed:        true,
+	model.StatusUserCancelled: true,
+	model.StatusError:         true,
+}
+
+// NewJobStatusHandler - JobStatusHandler 생성 (rdb: main에서 만든 공용 Redis 클라이언트)
+func NewJobStatusHandler(rdb *redis.Client) *JobStatusHandler {
+	cfg := config.GetConfig()
+
+	if rdb == nil {
+		log.Println("⚠️ [JobStatus] Redis not connected")
+		return nil
+	}
+
+	dbClient := database.NewClient()
+	if dbClient == nil {
+		log.Println("⚠️ [JobStatus] Failed to initialize Database client")
+		return nil
+	}
+
+	return &JobStatusHandler{
+		rdb:         rdb,
+		dbClient:    dbClient,
+		concurrency: cfg.WorkerMaxConcurrency,
+	}
+}
+
+// RegisterRoutes - 라우트 등록
+func (h *JobStatusHandler) RegisterRoutes(r *mux.Router) {
+	r.HandleFunc("/api/jobs/{jobId}", h.GetJob).Methods("GET", "OPTIONS")
+	r.HandleFunc("/api/jobs/{jobId}/events", h.StreamEvents).Methods("GET")
+	log.Println("✅ [JobStatus] Routes registered: GET /api/jobs/{jobId}, GET /api/jobs/{jobId}/events")
+}
+
+// GetJob - GET /api/jobs/{jobId}
+func (h *JobStatusHandler) GetJob(w http.ResponseWriter, r *http.Request) {
+	if r.Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+
+	jobID := mux.Vars(r)["jobId"]
+	job, err := h.dbClient.FetchJobFromSupabase(jobID)
+	if errors.Is(err, database.ErrJobNotFound) {
+		http.Error(w, `{"error": "Job not found"}`, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("❌ [JobStatus] Failed to fetch job %s: %v", jobID, err)
+		http.Error(w, `{"error": "Failed to fetch job"}`, http.StatusServiceUnavailable)
+		return
+	}
+
+	json.NewEncoder(w).Encode(h.statusResponse(job))
+}
+
+// statusResponse - Job 행 + 현재 Redis 큐 위치 (끝나지 않은 Job만)
+func (h *JobStatusHandler) statusResponse(job *model.ProductionJob) JobStatusResponse {
+	response := JobStatusResponse{
+		JobID:              job.JobID,
+		Status:             job.JobStatus,
+		JobType:            job.JobType,
+		ProductionPath:     productionPath(job),
+		ProductionID:       job.ProductionID,
+		TotalImages:        job.TotalImages,
+		CompletedImages:    job.CompletedImages,
+		FailedImages:       job.FailedImages,
+		GeneratedAttachIDs: job.GeneratedAttachIDs,
+		ErrorMessage:       job.ErrorMessage,
+		RetryCount:         job.RetryCount,
+		CreatedAt:          job.CreatedAt,
+		StartedAt:          job.StartedAt,
+		CompletedAt:        job.CompletedAt,
+		UpdatedAt:          job.UpdatedAt,
+	}
+	if job.TotalImages > 0 {
+		response.Progress = float64(job.CompletedImages) * 100 / float64(job.TotalImages)
+	}
+	if response.GeneratedAttachIDs == nil {
+		response.GeneratedAttachIDs = []interface{}{}
+	}
+
+	if !finishedStatuses[job.JobStatus] {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		position, err := locateJob(ctx, h.rdb, job.JobID, h.concurrency)
+		if err != nil {
+			// 큐 조회 실패는 상태 응답을 막지 않음
+			log.Printf("⚠️ [JobStatus] Failed to locate job %s in queue: %v", job.JobID, err)
+		} else {
+			response.Queue = position
+		}
+	}
+
+	return response
+}
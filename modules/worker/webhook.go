@@ -0,0 +1,525 @@
+package worker
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+
+	"quel-canvas-server/modules/common/config"
+	"quel-canvas-server/modules/common/credit"
+	"quel-canvas-server/modules/common/database"
+	"quel-canvas-server/modules/common/jobevents"
+	"quel-canvas-server/modules/common/model"
+)
+
+// Webhook Redis 키
+// webhooks:delivery:{id} - 전송 기록 (JSON, webhookRetention 동안 보관)
+// webhooks:deliveries - 전송 ID (score: 생성 시각, 최신순 목록용)
+// webhooks:job:{jobId} - Job별 전송 ID 목록
+// webhooks:schedule - 다음 시도가 예약된 전송 ID (score: 시도 시각)
+// webhooks:sent:{jobId}:{event} - 이미 전송을 만든 Job 이벤트 (중복 summary/인스턴스 간 중복 방지)
+const (
+	webhookDeliveryPrefix = "webhooks:delivery:"
+	webhookDeliveriesKey  = "webhooks:deliveries"
+	webhookJobPrefix      = "webhooks:job:"
+	webhookScheduleKey    = "webhooks:schedule"
+	webhookSentPrefix     = "webhooks:sent:"
+
+	webhookRetention    = 7 * 24 * time.Hour
+	webhookTimeout      = 10 * time.Second
+	webhookBackoffBase  = 30 * time.Second
+	webhookBackoffMax   = time.Hour
+	webhookPollInterval = 5 * time.Second
+	webhookPollBatch    = 20
+)
+
+// 전송 상태
+const (
+	webhookPending   = "pending"
+	webhookDelivered = "delivered"
+	webhookFailed    = "failed"
+)
+
+// webhookPayload - 콜백 URL로 POST하는 본문
+type webhookPayload struct {
+	Event           string    `json:"event"` // job.completed / job.failed / job.cancelled
+	JobID           string    `json:"jobId"`
+	Status          string    `json:"status"`
+	OrgID           string    `json:"orgId,omitempty"`
+	ProductionID    string    `json:"productionId,omitempty"`
+	TotalImages     int       `json:"totalImages"`
+	CompletedImages int       `json:"completedImages"`
+	AttachIDs       []int     `json:"attachIds"`
+	URLs            []string  `json:"urls"`
+	CreditsCharged  int       `json:"creditsCharged"`
+	ErrorMessage    string    `json:"errorMessage,omitempty"`
+	FinishedAt      time.Time `json:"finishedAt"`
+}
+
+// webhookAttempt - 전송 시도 한 번의 결과
+type webhookAttempt struct {
+	Attempt    int       `json:"attempt"`
+	At         time.Time `json:"at"`
+	StatusCode int       `json:"statusCode,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	DurationMs int64     `json:"durationMs"`
+	Manual     bool      `json:"manual,omitempty"` // 관리자 재전송
+}
+
+// webhookDelivery - 전송 기록 (delivery log)
+type webhookDelivery struct {
+	ID            string           `json:"id"`
+	JobID         string           `json:"jobId"`
+	OrgID         string           `json:"orgId,omitempty"`
+	URL           string           `json:"url"`
+	Event         string           `json:"event"`
+	Payload       json.RawMessage  `json:"payload"`
+	Status        string           `json:"status"`
+	Attempts      []webhookAttempt `json:"attempts"`
+	NextAttemptAt *time.Time       `json:"nextAttemptAt,omitempty"`
+	CreatedAt     time.Time        `json:"createdAt"`
+	UpdatedAt     time.Time        `json:"updatedAt"`
+}
+
+// webhookDispatcher - Job 종료 시 콜백 전송/재시도
+type webhookDispatcher struct {
+	rdb           *redis.Client
+	dbClient      *database.Client
+	credits       *credit.Client
+	defaultSecret string
+	maxAttempts   int
+	client        *http.Client
+}
+
+func newWebhookDispatcher(rdb *redis.Client, dbClient *database.Client, cfg *config.Config) *webhookDispatcher {
+	// 연결 시점에 실제 IP를 확인 (DNS rebinding/리다이렉트로 내부 주소에 닿지 않도록, 프록시 미사용)
+	dialer := &net.Dialer{Timeout: webhookTimeout, Control: publicAddressOnly}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &webhookDispatcher{
+		rdb:           rdb,
+		dbClient:      dbClient,
+		credits:       credit.NewClient(),
+		defaultSecret: cfg.WebhookSigningSecret,
+		maxAttempts:   cfg.WebhookMaxAttempts,
+		client:        &http.Client{Timeout: webhookTimeout, Transport: transport},
+	}
+}
+
+func webhookDeliveryKey(deliveryID string) string {
+	return webhookDeliveryPrefix + deliveryID
+}
+
+func webhookJobKey(jobID string) string {
+	return webhookJobPrefix + jobID
+}
+
+func webhookSentKey(jobID string, event string) string {
+	return webhookSentPrefix + jobID + ":" + event
+}
+
+// webhookEventName - Job 상태 → 웹훅 이벤트 이름
+func webhookEventName(status string) string {
+	switch status {
+	case model.StatusCompleted:
+		return "job.completed"
+	case model.StatusUserCancelled:
+		return "job.cancelled"
+	default:
+		return "job.failed"
+	}
+}
+
+// 공인 주소로 보지 않는 대역 (IsPrivate/IsLoopback 등으로 걸러지지 않는 것)
+var nonPublicNets = []*net.IPNet{
+	mustParseCIDR("0.0.0.0/8"),
+	mustParseCIDR("100.64.0.0/10"), // CGNAT
+	mustParseCIDR("192.0.0.0/24"),
+	mustParseCIDR("198.18.0.0/15"),
+}
+
+func mustParseCIDR(cidr string) *net.IPNet {
+	_, ipNet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		panic(err)
+	}
+	return ipNet
+}
+
+// publicIP - 사설/루프백/링크 로컬(169.254.169.254 메타데이터 포함)/멀티캐스트가 아닌 주소
+func publicIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsMulticast() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() {
+		return false
+	}
+	for _, ipNet := range nonPublicNets {
+		if ipNet.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// publicAddressOnly - 웹훅 연결 직전 대상 IP 확인 (net.Dialer.Control)
+func publicAddressOnly(network string, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || !publicIP(ip) {
+		return fmt.Errorf("webhook target %s is not a public address", host)
+	}
+	return nil
+}
+
+// validCallbackURL - 공인 호스트의 https 절대 URL만 허용 (IP 직접 지정은 공인 주소만, 이름은 연결 시 확인)
+func validCallbackURL(raw string) bool {
+	parsed, err := url.Parse(raw)
+	if err != nil || parsed.Scheme != "https" || parsed.Hostname() == "" {
+		return false
+	}
+	host := strings.ToLower(strings.TrimSuffix(parsed.Hostname(), "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") || strings.HasSuffix(host, ".internal") {
+		return false
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		return publicIP(ip)
+	}
+	return true
+}
+
+// callbackURL - job_input_data의 콜백 URL 우선, 없으면 조직 웹훅
+func (d *webhookDispatcher) callbackURL(job *model.ProductionJob) (string, error) {
+	for _, key := range []string{"callbackUrl", "callback_url"} {
+		if value, ok := job.JobInputData[key].(string); ok && value != "" {
+			if !validCallbackURL(value) {
+				return "", fmt.Errorf("invalid callback URL in job_input_data: %s", value)
+			}
+			return value, nil
+		}
+	}
+
+	if job.OrgID == nil || *job.OrgID == "" {
+		return "", nil
+	}
+	webhook, err := d.dbClient.FetchOrgWebhook(*job.OrgID)
+	if err != nil {
+		return "", err
+	}
+	if webhook == nil || !webhook.Enabled {
+		return "", nil
+	}
+	// 저장 시 검증하지만 DB에서 직접 바뀐 값도 내부 주소로 보내지 않도록 다시 확인
+	if !validCallbackURL(webhook.URL) {
+		return "", fmt.Errorf("invalid org webhook URL: %s", webhook.URL)
+	}
+	return webhook.URL, nil
+}
+
+// handleFinished - jobevents summary 이벤트 (completed/failed/user_cancelled) 수신 시 전송 예약
+func (d *webhookDispatcher) handleFinished(event jobevents.Event) {
+	job, err := d.dbClient.FetchJobFromSupabase(event.JobID)
+	if err != nil {
+		log.Printf("❌ [Webhook] Failed to fetch job %s: %v", event.JobID, err)
+		return
+	}
+
+	target, err := d.callbackURL(job)
+	if err != nil {
+		log.Printf("⚠️ [Webhook] No callback for job %s: %v", job.JobID, err)
+		return
+	}
+	if target == "" {
+		return
+	}
+
+	payload := webhookPayload{
+		Event:           webhookEventName(event.Status),
+		JobID:           job.JobID,
+		Status:          event.Status,
+		TotalImages:     job.TotalImages,
+		CompletedImages: job.CompletedImages,
+		AttachIDs:       event.AttachIDs,
+		URLs:            event.URLs,
+		FinishedAt:      event.Timestamp,
+	}
+	if job.OrgID != nil {
+		payload.OrgID = *job.OrgID
+	}
+	if job.ProductionID != nil {
+		payload.ProductionID = *job.ProductionID
+	}
+	if job.ErrorMessage != nil {
+		payload.ErrorMessage = *job.ErrorMessage
+	}
+	if len(payload.AttachIDs) == 0 {
+		for _, id := range job.GeneratedAttachIDs {
+			if floatID, ok := id.(float64); ok {
+				payload.AttachIDs = append(payload.AttachIDs, int(floatID))
+			}
+		}
+	}
+	if payload.AttachIDs == nil {
+		payload.AttachIDs = []int{}
+	}
+	if payload.URLs == nil {
+		payload.URLs = []string{}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), webhookTimeout)
+	defer cancel()
+
+	if d.credits != nil {
+		charged, err := d.credits.ChargedForAttaches(ctx, payload.AttachIDs)
+		if err != nil {
+			log.Printf("⚠️ [Webhook] Failed to sum credits for job %s: %v", job.JobID, err)
+		}
+		payload.CreditsCharged = charged
+	}
+
+	// 같은 Job 이벤트는 한 번만 전송 (summary가 여러 번 발행되거나 여러 인스턴스가 받는 경우)
+	sentKey := webhookSentKey(job.JobID, payload.Event)
+	first, err := d.rdb.SetNX(ctx, sentKey, time.Now().Unix(), webhookRetention).Result()
+	if err != nil {
+		log.Printf("❌ [Webhook] Failed to check delivery for job %s: %v", job.JobID, err)
+		return
+	}
+	if !first {
+		log.Printf("⏭️ [Webhook] %s for job %s already scheduled - skipping duplicate", payload.Event, job.JobID)
+		return
+	}
+
+	delivery, err := d.createDelivery(ctx, payload, target)
+	if err != nil {
+		log.Printf("❌ [Webhook] Failed to create delivery for job %s: %v", job.JobID, err)
+		d.rdb.Del(ctx, sentKey)
+		return
+	}
+	log.Printf("📮 [Webhook] Delivery %s scheduled for job %s → %s", delivery.ID, job.JobID, target)
+}
+
+// createDelivery - 전송 기록 생성 후 즉시 시도하도록 예약
+func (d *webhookDispatcher) createDelivery(ctx context.Context, payload webhookPayload, target string) (*webhookDelivery, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	delivery := &webhookDelivery{
+		ID:            uuid.NewString(),
+		JobID:         payload.JobID,
+		OrgID:         payload.OrgID,
+		URL:           target,
+		Event:         payload.Event,
+		Payload:       body,
+		Status:        webhookPending,
+		Attempts:      []webhookAttempt{},
+		NextAttemptAt: &now,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	if err := d.saveDelivery(ctx, delivery); err != nil {
+		return nil, err
+	}
+
+	pipe := d.rdb.TxPipeline()
+	pipe.ZAdd(ctx, webhookDeliveriesKey, redis.Z{Score: float64(now.UnixMilli()), Member: delivery.ID})
+	pipe.RPush(ctx, webhookJobKey(payload.JobID), delivery.ID)
+	pipe.Expire(ctx, webhookJobKey(payload.JobID), webhookRetention)
+	pipe.ZAdd(ctx, webhookScheduleKey, redis.Z{Score: float64(now.UnixMilli()), Member: delivery.ID})
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+func (d *webhookDispatcher) saveDelivery(ctx context.Context, delivery *webhookDelivery) error {
+	data, err := json.Marshal(delivery)
+	if err != nil {
+		return err
+	}
+	return d.rdb.Set(ctx, webhookDeliveryKey(delivery.ID), data, webhookRetention).Err()
+}
+
+// loadDelivery - 전송 기록 조회 (없으면 nil)
+func (d *webhookDispatcher) loadDelivery(ctx context.Context, deliveryID string) (*webhookDelivery, error) {
+	data, err := d.rdb.Get(ctx, webhookDeliveryKey(deliveryID)).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var delivery webhookDelivery
+	if err := json.Unmarshal(data, &delivery); err != nil {
+		return nil, fmt.Errorf("failed to parse webhook delivery: %w", err)
+	}
+	return &delivery, nil
+}
+
+// run - 예약된 전송을 주기적으로 처리 (여러 인스턴스가 돌아도 ZREM에 성공한 쪽만 전송)
+func (d *webhookDispatcher) run(ctx context.Context) {
+	ticker := time.NewTicker(webhookPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			d.processDue()
+		}
+	}
+}
+
+func (d *webhookDispatcher) processDue() {
+	ctx, cancel := context.WithTimeout(context.Background(), queueOpTimeout)
+	defer cancel()
+
+	due, err := d.rdb.ZRangeByScore(ctx, webhookScheduleKey, &redis.ZRangeBy{
+		Min:   "-inf",
+		Max:   strconv.FormatInt(time.Now().UnixMilli(), 10),
+		Count: webhookPollBatch,
+	}).Result()
+	if err != nil {
+		log.Printf("❌ [Webhook] Failed to scan schedule: %v", err)
+		return
+	}
+
+	for _, deliveryID := range due {
+		claimed, err := d.rdb.ZRem(ctx, webhookScheduleKey, deliveryID).Result()
+		if err != nil || claimed == 0 {
+			continue
+		}
+		go d.attempt(deliveryID, false)
+	}
+}
+
+// attempt - 전송 한 번 시도 후 결과 기록 (자동 시도는 실패 시 지수 백오프로 재예약)
+func (d *webhookDispatcher) attempt(deliveryID string, manual bool) (*webhookDelivery, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*webhookTimeout)
+	defer cancel()
+
+	delivery, err := d.loadDelivery(ctx, deliveryID)
+	if err != nil || delivery == nil {
+		return nil, err
+	}
+
+	// 서명 없이는 보내지 않음 (secret이 없으면 재시도 없이 실패로 기록)
+	secret, err := d.signingSecret(delivery.OrgID)
+	var result webhookAttempt
+	switch {
+	case err != nil:
+		result = webhookAttempt{Attempt: len(delivery.Attempts) + 1, At: time.Now(), Error: "failed to load signing secret: " + err.Error()}
+	case secret == "":
+		result = webhookAttempt{Attempt: len(delivery.Attempts) + 1, At: time.Now(), Error: "no signing secret (org webhook secret or WEBHOOK_SIGNING_SECRET)"}
+	default:
+		result = d.send(ctx, delivery, len(delivery.Attempts)+1, secret)
+	}
+	result.Manual = manual
+	delivery.Attempts = append(delivery.Attempts, result)
+	delivery.UpdatedAt = time.Now()
+	delivery.NextAttemptAt = nil
+
+	succeeded := result.Error == "" && result.StatusCode >= 200 && result.StatusCode < 300
+	switch {
+	case succeeded:
+		delivery.Status = webhookDelivered
+		log.Printf("✅ [Webhook] Delivery %s for job %s succeeded (%d)", delivery.ID, delivery.JobID, result.StatusCode)
+	case (err == nil && secret == "") || manual || len(delivery.Attempts) >= d.maxAttempts:
+		delivery.Status = webhookFailed
+		log.Printf("❌ [Webhook] Delivery %s for job %s failed after %d attempts", delivery.ID, delivery.JobID, len(delivery.Attempts))
+	default:
+		delivery.Status = webhookPending
+		next := time.Now().Add(webhookBackoff(len(delivery.Attempts)))
+		delivery.NextAttemptAt = &next
+		d.rdb.ZAdd(ctx, webhookScheduleKey, redis.Z{Score: float64(next.UnixMilli()), Member: delivery.ID})
+		log.Printf("⚠️ [Webhook] Delivery %s for job %s failed (attempt %d), retrying at %v",
+			delivery.ID, delivery.JobID, len(delivery.Attempts), next.Format(time.RFC3339))
+	}
+
+	if err := d.saveDelivery(ctx, delivery); err != nil {
+		log.Printf("❌ [Webhook] Failed to save delivery %s: %v", delivery.ID, err)
+	}
+	return delivery, nil
+}
+
+// webhookBackoff - 30초부터 두 배씩, 최대 1시간
+func webhookBackoff(attempts int) time.Duration {
+	backoff := webhookBackoffBase
+	for i := 1; i < attempts && backoff < webhookBackoffMax; i++ {
+		backoff *= 2
+	}
+	return min(backoff, webhookBackoffMax)
+}
+
+// signingSecret - 조직 secret 우선, 없으면 WEBHOOK_SIGNING_SECRET (둘 다 없으면 빈 문자열)
+func (d *webhookDispatcher) signingSecret(orgID string) (string, error) {
+	if orgID != "" {
+		webhook, err := d.dbClient.FetchOrgWebhook(orgID)
+		if err != nil {
+			return "", err
+		}
+		if webhook != nil && webhook.Secret != "" {
+			return webhook.Secret, nil
+		}
+	}
+	return d.defaultSecret, nil
+}
+
+// signPayload - X-Quel-Signature 값 (t=<unix>,v1=<hex(HMAC-SHA256(secret, "<unix>.<body>"))>)
+func signPayload(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", timestamp)
+	mac.Write(body)
+	return fmt.Sprintf("t=%d,v1=%s", timestamp, hex.EncodeToString(mac.Sum(nil)))
+}
+
+// send - 서명한 본문을 콜백 URL로 POST
+func (d *webhookDispatcher) send(ctx context.Context, delivery *webhookDelivery, attempt int, secret string) webhookAttempt {
+	result := webhookAttempt{Attempt: attempt, At: time.Now()}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "quel-canvas-webhooks/1.0")
+	req.Header.Set("X-Quel-Event", delivery.Event)
+	req.Header.Set("X-Quel-Delivery", delivery.ID)
+	req.Header.Set("X-Quel-Signature", signPayload(secret, result.At.Unix(), delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	result.DurationMs = time.Since(result.At).Milliseconds()
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	defer resp.Body.Close()
+
+	result.StatusCode = resp.StatusCode
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		result.Error = resp.Status
+	}
+	return result
+}
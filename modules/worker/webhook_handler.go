@@ -0,0 +1,248 @@
+package worker
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/redis/go-redis/v9"
+	"quel-canvas-server/modules/common/config"
+	"quel-canvas-server/modules/common/database"
+	"quel-canvas-server/modules/common/model"
+)
+
+// WebhookHandler - 조직 웹훅 설정 / 전송 기록 / 재전송 관리자 API
+type WebhookHandler struct {
+	dbClient   *database.Client
+	dispatcher *webhookDispatcher
+}
+
+// NewWebhookHandler - WebhookHandler 생성 (rdb: main에서 만든 공용 Redis 클라이언트)
+func NewWebhookHandler(rdb *redis.Client) *WebhookHandler {
+	cfg := config.GetConfig()
+
+	if rdb == nil {
+		log.Println("⚠️ [Webhook] Redis not connected")
+		return nil
+	}
+
+	dbClient := database.NewClient()
+	if dbClient == nil {
+		log.Println("⚠️ [Webhook] Failed to initialize Database client")
+		return nil
+	}
+
+	return &WebhookHandler{
+		dbClient:   dbClient,
+		dispatcher: newWebhookDispatcher(rdb, dbClient, cfg),
+	}
+}
+
+// RegisterRoutes - 라우트 등록 (auth: 관리자 인증 미들웨어)
+func (h *WebhookHandler) RegisterRoutes(r *mux.Router, auth func(http.HandlerFunc) http.HandlerFunc) {
+	r.HandleFunc("/admin/orgs/{orgId}/webhook", auth(h.GetOrgWebhook)).Methods("GET")
+	r.HandleFunc("/admin/orgs/{orgId}/webhook", auth(h.PutOrgWebhook)).Methods("PUT")
+	r.HandleFunc("/admin/orgs/{orgId}/webhook", auth(h.DeleteOrgWebhook)).Methods("DELETE")
+	r.HandleFunc("/admin/webhooks/deliveries", auth(h.ListDeliveries)).Methods("GET")
+	r.HandleFunc("/admin/webhooks/deliveries/{deliveryId}", auth(h.GetDelivery)).Methods("GET")
+	r.HandleFunc("/admin/webhooks/deliveries/{deliveryId}/redeliver", auth(h.Redeliver)).Methods("POST")
+	log.Println("✅ [Webhook] Routes registered: /admin/orgs/{orgId}/webhook, /admin/webhooks/deliveries")
+}
+
+// GetOrgWebhook - GET /admin/orgs/{orgId}/webhook
+func (h *WebhookHandler) GetOrgWebhook(w http.ResponseWriter, r *http.Request) {
+	orgID := mux.Vars(r)["orgId"]
+
+	webhook, err := h.dbClient.FetchOrgWebhook(orgID)
+	if err != nil {
+		log.Printf("❌ [Webhook] Failed to fetch org %s webhook: %v", orgID, err)
+		http.Error(w, `{"error": "Failed to fetch webhook"}`, http.StatusInternalServerError)
+		return
+	}
+	if webhook == nil {
+		http.Error(w, `{"error": "Webhook not configured"}`, http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(webhook)
+}
+
+// PutOrgWebhook - PUT /admin/orgs/{orgId}/webhook
+// Body: {"url": "https://...", "secret": "...", "enabled": true}
+// secret을 생략하면 기존 secret 유지, 기존 secret도 없으면 새로 생성 (응답에 포함)
+func (h *WebhookHandler) PutOrgWebhook(w http.ResponseWriter, r *http.Request) {
+	orgID := mux.Vars(r)["orgId"]
+
+	var req struct {
+		URL     string `json:"url"`
+		Secret  string `json:"secret"`
+		Enabled *bool  `json:"enabled"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, `{"error": "Invalid request body"}`, http.StatusBadRequest)
+		return
+	}
+	if !validCallbackURL(req.URL) {
+		http.Error(w, `{"error": "url must be an absolute https URL on a public host"}`, http.StatusBadRequest)
+		return
+	}
+
+	existing, err := h.dbClient.FetchOrgWebhook(orgID)
+	if err != nil {
+		log.Printf("❌ [Webhook] Failed to fetch org %s webhook: %v", orgID, err)
+		http.Error(w, `{"error": "Failed to fetch webhook"}`, http.StatusInternalServerError)
+		return
+	}
+
+	webhook := &model.OrgWebhook{OrgID: orgID, URL: req.URL, Secret: req.Secret, Enabled: true}
+	if req.Enabled != nil {
+		webhook.Enabled = *req.Enabled
+	}
+	if webhook.Secret == "" && existing != nil {
+		webhook.Secret = existing.Secret
+	}
+	if webhook.Secret == "" {
+		secret := make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			http.Error(w, `{"error": "Failed to generate secret"}`, http.StatusInternalServerError)
+			return
+		}
+		webhook.Secret = "whsec_" + hex.EncodeToString(secret)
+	}
+
+	if err := h.dbClient.UpsertOrgWebhook(webhook); err != nil {
+		log.Printf("❌ [Webhook] Failed to save org %s webhook: %v", orgID, err)
+		http.Error(w, `{"error": "Failed to save webhook"}`, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(webhook)
+}
+
+// DeleteOrgWebhook - DELETE /admin/orgs/{orgId}/webhook
+func (h *WebhookHandler) DeleteOrgWebhook(w http.ResponseWriter, r *http.Request) {
+	orgID := mux.Vars(r)["orgId"]
+
+	if err := h.dbClient.DeleteOrgWebhook(orgID); err != nil {
+		log.Printf("❌ [Webhook] Failed to delete org %s webhook: %v", orgID, err)
+		http.Error(w, `{"error": "Failed to delete webhook"}`, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"success": true, "orgId": orgID})
+}
+
+// ListDeliveries - GET /admin/webhooks/deliveries?jobId=&offset=0&limit=50 (최신순)
+func (h *WebhookHandler) ListDeliveries(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	offset, _ := strconv.ParseInt(query.Get("offset"), 10, 64)
+	if offset < 0 {
+		offset = 0
+	}
+	limit, _ := strconv.ParseInt(query.Get("limit"), 10, 64)
+	if limit <= 0 || limit > 500 {
+		limit = 50
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	rdb := h.dispatcher.rdb
+	var deliveryIDs []string
+	var total int64
+	var err error
+	if jobID := query.Get("jobId"); jobID != "" {
+		key := webhookJobKey(jobID)
+		if total, err = rdb.LLen(ctx, key).Result(); err == nil {
+			// Job별 목록은 오래된 순으로 쌓이므로 뒤에서부터
+			deliveryIDs, err = rdb.LRange(ctx, key, -offset-limit, -offset-1).Result()
+			for i, j := 0, len(deliveryIDs)-1; i < j; i, j = i+1, j-1 {
+				deliveryIDs[i], deliveryIDs[j] = deliveryIDs[j], deliveryIDs[i]
+			}
+		}
+	} else {
+		if total, err = rdb.ZCard(ctx, webhookDeliveriesKey).Result(); err == nil {
+			deliveryIDs, err = rdb.ZRevRange(ctx, webhookDeliveriesKey, offset, offset+limit-1).Result()
+		}
+	}
+	if err != nil {
+		log.Printf("❌ [Webhook] Failed to list deliveries: %v", err)
+		http.Error(w, `{"error": "Failed to list deliveries"}`, http.StatusInternalServerError)
+		return
+	}
+
+	deliveries := make([]webhookDelivery, 0, len(deliveryIDs))
+	for _, deliveryID := range deliveryIDs {
+		delivery, err := h.dispatcher.loadDelivery(ctx, deliveryID)
+		if err != nil {
+			log.Printf("⚠️ [Webhook] Failed to read delivery %s: %v", deliveryID, err)
+			continue
+		}
+		if delivery != nil {
+			deliveries = append(deliveries, *delivery)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"total":      total,
+		"offset":     offset,
+		"deliveries": deliveries,
+	})
+}
+
+// GetDelivery - GET /admin/webhooks/deliveries/{deliveryId} (본문 + 시도 기록)
+func (h *WebhookHandler) GetDelivery(w http.ResponseWriter, r *http.Request) {
+	deliveryID := mux.Vars(r)["deliveryId"]
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	delivery, err := h.dispatcher.loadDelivery(ctx, deliveryID)
+	if err != nil {
+		log.Printf("❌ [Webhook] Failed to read delivery %s: %v", deliveryID, err)
+		http.Error(w, `{"error": "Failed to read delivery"}`, http.StatusInternalServerError)
+		return
+	}
+	if delivery == nil {
+		http.Error(w, `{"error": "Delivery not found"}`, http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(delivery)
+}
+
+// Redeliver - POST /admin/webhooks/deliveries/{deliveryId}/redeliver
+// 같은 본문으로 즉시 한 번 전송 (새 서명), 예약된 자동 재시도는 취소
+func (h *WebhookHandler) Redeliver(w http.ResponseWriter, r *http.Request) {
+	deliveryID := mux.Vars(r)["deliveryId"]
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	h.dispatcher.rdb.ZRem(ctx, webhookScheduleKey, deliveryID)
+
+	delivery, err := h.dispatcher.attempt(deliveryID, true)
+	if err != nil {
+		log.Printf("❌ [Webhook] Failed to redeliver %s: %v", deliveryID, err)
+		http.Error(w, `{"error": "Failed to redeliver"}`, http.StatusInternalServerError)
+		return
+	}
+	if delivery == nil {
+		http.Error(w, `{"error": "Delivery not found"}`, http.StatusNotFound)
+		return
+	}
+
+	log.Printf("🔁 [Webhook] Delivery %s redelivered by admin: %s", deliveryID, delivery.Status)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(delivery)
+}
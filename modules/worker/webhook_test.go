@@ -0,0 +1,108 @@
+package worker
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestPublicIP(t *testing.T) {
+	cases := []struct {
+		ip   string
+		want bool
+	}{
+		{ip: "8.8.8.8", want: true},
+		{ip: "2606:4700:4700::1111", want: true},
+		{ip: "127.0.0.1", want: false},
+		{ip: "::1", want: false},
+		{ip: "10.1.2.3", want: false},
+		{ip: "172.16.0.1", want: false},
+		{ip: "192.168.1.10", want: false},
+		{ip: "169.254.169.254", want: false}, // 클라우드 메타데이터
+		{ip: "fe80::1", want: false},
+		{ip: "fd00::1", want: false},
+		{ip: "0.0.0.0", want: false},
+		{ip: "100.64.0.1", want: false}, // CGNAT
+		{ip: "198.18.0.1", want: false},
+		{ip: "224.0.0.1", want: false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.ip, func(t *testing.T) {
+			if got := publicIP(net.ParseIP(tc.ip)); got != tc.want {
+				t.Fatalf("publicIP(%s) = %v, want %v", tc.ip, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestValidCallbackURL(t *testing.T) {
+	cases := []struct {
+		url  string
+		want bool
+	}{
+		{url: "https://hooks.example.com/quel", want: true},
+		{url: "https://8.8.8.8/hook", want: true},
+		{url: "https://hooks.example.com:8443/quel?x=1", want: true},
+		{url: "http://hooks.example.com/quel", want: false},
+		{url: "https:///no-host", want: false},
+		{url: "/relative/path", want: false},
+		{url: "https://localhost/hook", want: false},
+		{url: "https://LOCALHOST./hook", want: false},
+		{url: "https://api.localhost/hook", want: false},
+		{url: "https://metadata.google.internal/computeMetadata", want: false},
+		{url: "https://127.0.0.1/hook", want: false},
+		{url: "https://169.254.169.254/latest/meta-data", want: false},
+		{url: "https://[::1]/hook", want: false},
+		{url: "https://10.0.0.5:8080/hook", want: false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.url, func(t *testing.T) {
+			if got := validCallbackURL(tc.url); got != tc.want {
+				t.Fatalf("validCallbackURL(%q) = %v, want %v", tc.url, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSignPayload(t *testing.T) {
+	body := []byte(`{"event":"job.completed"}`)
+	cases := []struct {
+		name   string
+		secret string
+		want   string
+	}{
+		{name: "org secret", secret: "whsec_test", want: "t=1700000000,v1=51be9920773f454007b9aaf2ef84578604f287a1ad8b1cf6918458c66aac6bd8"},
+		{name: "other secret", secret: "other", want: "t=1700000000,v1=f292f537f3361ea36923d5397f4b7a2a068dc85301bd37a95eef86df12e034f2"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := signPayload(tc.secret, 1700000000, body); got != tc.want {
+				t.Fatalf("signPayload = %s, want %s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestWebhookBackoff(t *testing.T) {
+	cases := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{attempts: 0, want: 30 * time.Second},
+		{attempts: 1, want: 30 * time.Second},
+		{attempts: 2, want: time.Minute},
+		{attempts: 3, want: 2 * time.Minute},
+		{attempts: 7, want: 32 * time.Minute},
+		{attempts: 8, want: time.Hour}, // 64분 → 최대 1시간
+		{attempts: 50, want: time.Hour},
+	}
+
+	for _, tc := range cases {
+		if got := webhookBackoff(tc.attempts); got != tc.want {
+			t.Errorf("webhookBackoff(%d) = %v, want %v", tc.attempts, got, tc.want)
+		}
+	}
+}
@@ -2,11 +2,17 @@ package worker
 
 import (
 	"context"
+	"fmt"
 	"log"
+	"runtime/debug"
 	"time"
 
+	"quel-canvas-server/modules/common/cancel"
 	"quel-canvas-server/modules/common/config"
 	"quel-canvas-server/modules/common/database"
+	"quel-canvas-server/modules/common/jobevents"
+	"quel-canvas-server/modules/common/model"
+	jobqueue "quel-canvas-server/modules/common/queue"
 	redisClient "quel-canvas-server/modules/common/redis"
 
 	"quel-canvas-server/modules/beauty"
@@ -33,6 +39,12 @@ func StartWorker() {
 	}
 	log.Println("✅ Redis connected successfully")
 
+	// Job 진행 이벤트 발행 (협업 서버가 jobs:progress 구독 후 Room으로 전달)
+	jobevents.Init(rdb)
+
+	// Job 취소 알림 구독 (jobs:cancel 수신 시 해당 Job 컨텍스트 취소 → 진행 중인 API 호출/대기 즉시 중단)
+	cancel.Watch(rdb)
+
 	// Database 클라이언트 초기화
 	dbClient := database.NewClient()
 	if dbClient == nil {
@@ -40,41 +52,112 @@ func StartWorker() {
 		return
 	}
 
-	// Queue 감시 시작
-	log.Println("👀 Watching queue: jobs:queue")
+	// 처리 중 목록 기반 Reliable Queue (워커가 죽어도 reaper가 Job을 대기열로 되돌림)
+	queue := newReliableQueue(rdb, cfg)
 
 	ctx := context.Background()
+	go queue.heartbeatLoop(ctx)
+	go queue.reapLoop(ctx)
+	queue.recoverOnStartup(dbClient, cfg.JobRecoveryWindow)
+
+	// Job 완료 웹훅 (summary 이벤트 발행 시 예약, 실패하면 지수 백오프로 재시도)
+	webhooks := newWebhookDispatcher(rdb, dbClient, cfg)
+	jobevents.OnFinished(webhooks.handleFinished)
+	go webhooks.run(ctx)
+
+	// Queue 감시 시작
+	log.Printf("👀 Watching queue: %s (worker: %s)", queueKey, queue.workerID)
+
+	// 동시 처리 제한 (슬롯이 없으면 Job은 Redis에 남아 대기)
+	pool := newWorkerPool(cfg.WorkerMaxConcurrency, cfg.WorkerPathConcurrency)
 
 	// 무한 루프로 Queue 감시
 	for {
-		// Job 받기 (BRPOP - Blocking Right Pop)
-		result, err := rdb.BRPop(ctx, 0, "jobs:queue").Result()
+		if !pool.acquire(ctx) {
+			return
+		}
+
+		// 레인 순서대로 이 워커의 처리 목록으로 원자적 이동 (각 레인은 슬롯이 빈 경로의 대기 목록 먼저)
+		jobID, lane, err := queue.next(ctx, pool.availablePaths())
 		if err != nil {
-			log.Printf("❌ Redis BRPOP error: %v", err)
+			log.Printf("❌ Redis dequeue error: %v", err)
+			pool.release()
 			time.Sleep(5 * time.Second)
 			continue
 		}
+		if jobID == "" {
+			pool.release()
+			continue
+		}
+
+		log.Printf("🎯 Received new job: %s (lane: %s)", jobID, lane)
 
-		// result[0]은 "jobs:queue", result[1]이 실제 job_id
-		jobID := result[1]
-		log.Printf("🎯 Received new job: %s", jobID)
+		// Supabase에서 Job 데이터 조회 (실패하면 버리지 않고 dead-letter에 기록)
+		job, err := dbClient.FetchJobFromSupabase(jobID)
+		if err != nil {
+			log.Printf("❌ Failed to fetch job %s: %v", jobID, err)
+			recordAttempt(ctx, rdb, jobID)
+			queue.deadLetter(jobID, "", fmt.Sprintf("failed to fetch job: %v", err))
+			queue.ack(jobID)
+			pool.release()
+			continue
+		}
 
-		// Job 처리 (goroutine으로 비동기)
-		go processJob(ctx, dbClient, jobID)
+		path := productionPath(job)
+		if !pool.tryAcquirePath(path) {
+			// retry로 돌아온 Job도 원래 레인의 대기 목록으로 (레인 가중치 유지)
+			waitLane, _ := jobqueue.JobLane(job)
+			log.Printf("⏳ %s concurrency limit reached - job %s waiting in %s", path, jobID, waitingKey(path, waitLane))
+			queue.park(jobID, path, lane, waitLane)
+			pool.release()
+			continue
+		}
+
+		// 시도 횟수 기록 (retry_count는 Job을 시작할 때마다 증가)
+		attempt := recordAttempt(ctx, rdb, jobID)
+		if err := dbClient.UpdateJobRetryCount(ctx, jobID, job.RetryCount+1); err != nil {
+			log.Printf("⚠️ Failed to update retry_count for job %s: %v", jobID, err)
+		}
+		log.Printf("🔁 Job %s attempt #%d on %s", jobID, attempt, path)
+
+		// Job 처리 (goroutine으로 비동기, 끝나면 처리 목록에서 제거하고 슬롯 반환)
+		go func() {
+			defer pool.release()
+			defer pool.releasePath(path)
+			defer queue.ack(jobID)
+
+			// 진행 이벤트를 보낼 org/workspace/요청자 등록 (panic 처리보다 먼저 등록하고 나중에 해제해 failed/summary도 전달)
+			jobevents.Track(job)
+			defer jobevents.Forget(jobID)
+
+			defer func() {
+				// 파이프라인 panic은 워커 전체를 죽이지 않고 dead-letter로
+				if r := recover(); r != nil {
+					log.Printf("💥 Pipeline panic in job %s: %v\n%s", jobID, r, debug.Stack())
+					reason := fmt.Sprintf("pipeline panic: %v", r)
+					queue.deadLetter(jobID, path, reason)
+					if err := dbClient.UpdateJobFailed(context.Background(), jobID, reason); err != nil {
+						log.Printf("❌ Failed to mark panicked job %s as failed: %v", jobID, err)
+					}
+					return
+				}
+				clearAttempts(context.Background(), rdb, jobID)
+			}()
+			jobCtx, release := cancel.WithJob(ctx, jobID)
+			defer release()
+
+			startedAt := time.Now()
+			processJob(jobCtx, job, path)
+			queue.recordDuration(time.Since(startedAt))
+		}()
 	}
 }
 
 // processJob - Job 처리 함수 (quel_production_path 기반 라우팅)
-func processJob(ctx context.Context, dbClient *database.Client, jobID string) {
+func processJob(ctx context.Context, job *model.ProductionJob, path string) {
+	jobID := job.JobID
 	log.Printf("🚀 Processing job: %s", jobID)
 
-	// Supabase에서 Job 데이터 조회
-	job, err := dbClient.FetchJobFromSupabase(jobID)
-	if err != nil {
-		log.Printf("❌ Failed to fetch job %s: %v", jobID, err)
-		return
-	}
-
 	// Job 데이터 로그 출력
 	log.Printf("📦 Job Data:")
 	log.Printf("   JobID: %s", job.JobID)
@@ -89,16 +172,10 @@ func processJob(ctx context.Context, dbClient *database.Client, jobID string) {
 		log.Printf("   ProductionID: null")
 	}
 
-	// job_type이 "modify"이거나 job_input_data에 maskDataUrl이 있으면 modify 모듈로 라우팅
-	// (DB 제약으로 인해 job_type은 simple_general로 저장되지만 maskDataUrl로 modify job 식별)
-	isModifyJob := job.JobType == "modify"
-	if !isModifyJob && job.JobInputData != nil {
-		if _, hasMask := job.JobInputData["maskDataUrl"]; hasMask {
-			isModifyJob = true
-		}
-	}
+	log.Printf("🔀 Routing to module: %s", path)
 
-	if isModifyJob {
+	switch path {
+	case "modify":
 		log.Printf("🎨 Routing to Modify module (detected via maskDataUrl)")
 		modifyService := modify.NewService()
 		if modifyService != nil {
@@ -109,22 +186,6 @@ func processJob(ctx context.Context, dbClient *database.Client, jobID string) {
 			log.Printf("❌ Failed to initialize Modify service")
 		}
 		return
-	}
-
-	// quel_production_path 기반 라우팅 (기존 로직)
-	path := job.QuelProductionPath
-
-	// NULL 또는 빈 문자열은 fashion으로 처리
-	if path == "" {
-		path = "fashion"
-	}
-
-	log.Printf("🔀 Routing to module: %s", path)
-
-	switch path {
-	case "fashion":
-		log.Printf("👗 Routing to Fashion module")
-		fashion.ProcessJob(ctx, job)
 
 	case "beauty":
 		log.Printf("💄 Routing to Beauty module")
@@ -151,7 +212,7 @@ func processJob(ctx context.Context, dbClient *database.Client, jobID string) {
 		landingdemo.ProcessJob(ctx, job)
 
 	default:
-		log.Printf("⚠️  Unknown quel_production_path: %s, using Fashion as default", path)
+		log.Printf("👗 Routing to Fashion module")
 		fashion.ProcessJob(ctx, job)
 	}
 
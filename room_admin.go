@@ -0,0 +1,378 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// 관리자 API 토큰 (main에서 ADMIN_API_TOKEN으로 설정, 비어있으면 관리자 API 비활성화)
+var adminToken string
+
+// 강퇴된 사용자가 같은 Room에 다시 접속할 수 없는 시간
+const kickDenyTTL = 2 * time.Minute
+
+// 강퇴 차단 키 (collab:room:{room}:deny:{userId}, 다른 인스턴스 재접속도 차단)
+const roomDenySuffix = ":deny:"
+
+func roomDenyKey(roomKey string, userId string) string {
+	return roomChannelPrefix + roomKey + roomDenySuffix + userId
+}
+
+// 이 인스턴스의 강퇴 차단 목록 (Redis 미사용/오류 시에도 차단, "room\x00user" → 만료 시각)
+var kickDenies = struct {
+	sync.Mutex
+	until map[string]time.Time
+}{until: make(map[string]time.Time)}
+
+// 관리자 요청 본문 (kick/close/notice 공용)
+type adminRequest struct {
+	Reason  string `json:"reason"`
+	Message string `json:"message"`
+	Level   string `json:"level"` // info | warning | critical
+}
+
+// 로컬 접속자 정보
+type adminParticipant struct {
+	UserId      string `json:"userId"`
+	UserName    string `json:"userName"`
+	Role        string `json:"role"`
+	OrgId       string `json:"orgId"`
+	WorkspaceId string `json:"workspaceId"`
+}
+
+// registerAdminRoutes - Room 관리자 API 라우트 등록
+func registerAdminRoutes(r *mux.Router) {
+	r.HandleFunc("/admin/rooms/{roomKey}/participants", requireAdmin(listRoomParticipants)).Methods("GET")
+	r.HandleFunc("/admin/rooms/{roomKey}/participants/{userId}/kick", requireAdmin(kickRoomParticipant)).Methods("POST")
+	r.HandleFunc("/admin/rooms/{roomKey}/close", requireAdmin(closeRoom)).Methods("POST")
+	r.HandleFunc("/admin/rooms/{roomKey}/notices", requireAdmin(postRoomNotice)).Methods("POST")
+	r.HandleFunc("/admin/notices", requireAdmin(postSystemNotice)).Methods("POST")
+}
+
+// requireAdmin - Authorization: Bearer <ADMIN_API_TOKEN> 또는 X-Admin-Token 확인
+func requireAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if adminToken == "" {
+			http.Error(w, `{"error": "admin API disabled (ADMIN_API_TOKEN not set)"}`, http.StatusServiceUnavailable)
+			return
+		}
+
+		token := r.Header.Get("X-Admin-Token")
+		if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+			token = strings.TrimPrefix(auth, "Bearer ")
+		}
+		if subtle.ConstantTimeCompare([]byte(token), []byte(adminToken)) != 1 {
+			log.Printf("🚫 [Admin] Unauthorized %s %s from %s", r.Method, r.URL.Path, r.RemoteAddr)
+			http.Error(w, `{"error": "unauthorized"}`, http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// requireAdminIfConfigured - 토큰이 설정된 경우에만 인증 (기존 /admin/cleanup 호환)
+func requireAdminIfConfigured(next http.HandlerFunc) http.HandlerFunc {
+	protected := requireAdmin(next)
+	return func(w http.ResponseWriter, r *http.Request) {
+		if adminToken == "" {
+			next(w, r)
+			return
+		}
+		protected(w, r)
+	}
+}
+
+func decodeAdminRequest(r *http.Request) (adminRequest, error) {
+	var req adminRequest
+	if r.Body == nil || r.ContentLength == 0 {
+		return req, nil
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return req, fmt.Errorf("invalid request body: %w", err)
+	}
+	return req, nil
+}
+
+// roomExists - 이 인스턴스 또는 다른 인스턴스에 접속자가 있는 Room인지
+func roomExists(roomKey string) bool {
+	if sessionManager.getSession(roomKey) != nil {
+		return true
+	}
+	return len(roomBus.participants(roomKey)) > 0
+}
+
+// GET /admin/rooms/{roomKey}/participants
+func listRoomParticipants(w http.ResponseWriter, r *http.Request) {
+	roomKey := mux.Vars(r)["roomKey"]
+
+	session := sessionManager.getSession(roomKey)
+	participants := roomBus.participants(roomKey)
+	if session == nil && len(participants) == 0 {
+		http.Error(w, `{"error": "room not found"}`, http.StatusNotFound)
+		return
+	}
+
+	local := []adminParticipant{}
+	if session != nil {
+		local = session.participants()
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"roomKey":      roomKey,
+		"local":        local,
+		"participants": participants, // 전체 인스턴스 기준 (Redis 미사용 시 null)
+	})
+}
+
+// POST /admin/rooms/{roomKey}/participants/{userId}/kick
+func kickRoomParticipant(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	roomKey, userId := vars["roomKey"], vars["userId"]
+
+	req, err := decodeAdminRequest(r)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error": %q}`, err.Error()), http.StatusBadRequest)
+		return
+	}
+	if !roomExists(roomKey) {
+		http.Error(w, `{"error": "room not found"}`, http.StatusNotFound)
+		return
+	}
+
+	// 연결을 끊기 전에 차단해서 클라이언트 자동 재접속도 거부
+	denyRoomUser(roomKey, userId)
+
+	message := Message{Type: "user-kicked", TargetUserId: userId, Reason: req.Reason}
+	if session := sessionManager.getSession(roomKey); session != nil {
+		session.broadcastToAll(message)
+		session.disconnectLocal(userId)
+	} else {
+		publishAdminMessage(roomKey, message)
+	}
+
+	log.Printf("🥾 [Admin] Kicked %s from room %s (reason: %s)", userId, roomKey, req.Reason)
+	json.NewEncoder(w).Encode(map[string]string{"status": "kicked", "roomKey": roomKey, "userId": userId})
+}
+
+// denyRoomUser - kickDenyTTL 동안 Room 재접속 차단 (로컬 + Redis)
+func denyRoomUser(roomKey string, userId string) {
+	now := time.Now()
+	kickDenies.Lock()
+	for key, until := range kickDenies.until {
+		if now.After(until) {
+			delete(kickDenies.until, key)
+		}
+	}
+	kickDenies.until[roomKey+"\x00"+userId] = now.Add(kickDenyTTL)
+	kickDenies.Unlock()
+
+	roomBus.deny(roomKey, userId)
+}
+
+// isRoomUserDenied - 강퇴 후 차단 시간이 지나지 않은 사용자인지
+func isRoomUserDenied(roomKey string, userId string) bool {
+	kickDenies.Lock()
+	until, ok := kickDenies.until[roomKey+"\x00"+userId]
+	kickDenies.Unlock()
+	if ok && time.Now().Before(until) {
+		return true
+	}
+	return roomBus.denied(roomKey, userId)
+}
+
+// deny - 다른 인스턴스도 확인할 수 있도록 Redis에 차단 기록
+func (b *RoomBus) deny(roomKey string, userId string) {
+	if b == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), busTimeout)
+	defer cancel()
+
+	if err := b.rdb.Set(ctx, roomDenyKey(roomKey, userId), time.Now().Unix(), kickDenyTTL).Err(); err != nil {
+		log.Printf("❌ [RoomBus] Failed to deny %s in %s: %v", userId, roomKey, err)
+	}
+}
+
+// denied - Redis 차단 기록 확인 (오류 시 허용)
+func (b *RoomBus) denied(roomKey string, userId string) bool {
+	if b == nil {
+		return false
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), busTimeout)
+	defer cancel()
+
+	count, err := b.rdb.Exists(ctx, roomDenyKey(roomKey, userId)).Result()
+	if err != nil {
+		log.Printf("❌ [RoomBus] Failed to check deny for %s in %s: %v", userId, roomKey, err)
+		return false
+	}
+	return count > 0
+}
+
+// POST /admin/rooms/{roomKey}/close
+func closeRoom(w http.ResponseWriter, r *http.Request) {
+	roomKey := mux.Vars(r)["roomKey"]
+
+	req, err := decodeAdminRequest(r)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error": %q}`, err.Error()), http.StatusBadRequest)
+		return
+	}
+	if !roomExists(roomKey) {
+		http.Error(w, `{"error": "room not found"}`, http.StatusNotFound)
+		return
+	}
+
+	message := Message{Type: "room-closed", Reason: req.Reason}
+	if session := sessionManager.getSession(roomKey); session != nil {
+		session.broadcastToAll(message)
+		sessionManager.closeSession(roomKey)
+	} else {
+		publishAdminMessage(roomKey, message)
+	}
+
+	log.Printf("🚪 [Admin] Closed room %s (reason: %s)", roomKey, req.Reason)
+	json.NewEncoder(w).Encode(map[string]string{"status": "closed", "roomKey": roomKey})
+}
+
+// POST /admin/rooms/{roomKey}/notices
+func postRoomNotice(w http.ResponseWriter, r *http.Request) {
+	roomKey := mux.Vars(r)["roomKey"]
+
+	req, err := decodeAdminRequest(r)
+	if err != nil || req.Message == "" {
+		http.Error(w, `{"error": "message is required"}`, http.StatusBadRequest)
+		return
+	}
+	if !roomExists(roomKey) {
+		http.Error(w, `{"error": "room not found"}`, http.StatusNotFound)
+		return
+	}
+
+	message := systemNotice(req)
+	if session := sessionManager.getSession(roomKey); session != nil {
+		session.broadcastToAll(message)
+	} else {
+		publishAdminMessage(roomKey, message)
+	}
+
+	log.Printf("📣 [Admin] Notice to room %s: %s", roomKey, req.Message)
+	json.NewEncoder(w).Encode(map[string]string{"status": "sent", "roomKey": roomKey})
+}
+
+// POST /admin/notices - 모든 인스턴스의 모든 연결에 공지
+func postSystemNotice(w http.ResponseWriter, r *http.Request) {
+	req, err := decodeAdminRequest(r)
+	if err != nil || req.Message == "" {
+		http.Error(w, `{"error": "message is required"}`, http.StatusBadRequest)
+		return
+	}
+
+	payload, err := json.Marshal(systemNotice(req))
+	if err != nil {
+		http.Error(w, `{"error": "failed to encode notice"}`, http.StatusInternalServerError)
+		return
+	}
+	rooms := sessionManager.deliverToAll(payload)
+	roomBus.publishSystem(payload)
+
+	log.Printf("📣 [Admin] System notice to %d local rooms: %s", rooms, req.Message)
+	json.NewEncoder(w).Encode(map[string]interface{}{"status": "sent", "localRooms": rooms})
+}
+
+func systemNotice(req adminRequest) Message {
+	level := req.Level
+	if level == "" {
+		level = "info"
+	}
+	return Message{
+		Type: "system-notice",
+		Data: map[string]interface{}{
+			"message": req.Message,
+			"level":   level,
+		},
+	}
+}
+
+// publishAdminMessage - 이 인스턴스에 세션이 없는 Room으로 직접 발행 (순번 없음)
+func publishAdminMessage(roomKey string, message Message) {
+	payload, err := json.Marshal(message)
+	if err != nil {
+		log.Printf("Error marshaling admin message: %v", err)
+		return
+	}
+	roomBus.publish(roomKey, "", payload)
+}
+
+// participants - 이 인스턴스의 접속자 목록
+func (s *Session) participants() []adminParticipant {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	participants := make([]adminParticipant, 0, len(s.clients))
+	for _, client := range s.clients {
+		participants = append(participants, adminParticipant{
+			UserId:      client.userId,
+			UserName:    client.userName,
+			Role:        client.getRole(),
+			OrgId:       client.orgId,
+			WorkspaceId: client.workspaceId,
+		})
+	}
+	return participants
+}
+
+// closeSession - 세션 즉시 종료 (남은 메시지 전달 후 연결 종료)
+func (sm *SessionManager) closeSession(sessionId string) bool {
+	sm.mutex.Lock()
+	session, exists := sm.sessions[sessionId]
+	if exists {
+		delete(sm.sessions, sessionId)
+	}
+	sm.mutex.Unlock()
+	if !exists {
+		return false
+	}
+
+	session.stop(true)
+	if session.hasPendingSnapshot() {
+		go session.flushSnapshot()
+	}
+	roomBus.unsubscribe(sessionId)
+
+	sm.metrics.mutex.Lock()
+	sm.metrics.ActiveSessions--
+	sm.metrics.mutex.Unlock()
+	return true
+}
+
+// listSessions - 이 인스턴스의 세션 목록 (잠금 없이 순회하기 위한 복사본)
+func (sm *SessionManager) listSessions() []*Session {
+	sm.mutex.RLock()
+	defer sm.mutex.RUnlock()
+
+	sessions := make([]*Session, 0, len(sm.sessions))
+	for _, session := range sm.sessions {
+		sessions = append(sessions, session)
+	}
+	return sessions
+}
+
+// deliverToAll - 이 인스턴스의 모든 세션에 전달 (순번/재전송 없음), 전달한 세션 수 반환
+func (sm *SessionManager) deliverToAll(payload []byte) int {
+	sessions := sm.listSessions()
+	for _, session := range sessions {
+		session.deliverLocal("", "", payload)
+	}
+	return len(sessions)
+}
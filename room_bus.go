@@ -0,0 +1,249 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+
+	"quel-canvas-server/modules/common/jobevents"
+)
+
+// Room Pub/Sub 관련 Redis 키
+const (
+	roomChannelPrefix  = "collab:room:"
+	roomPresenceSuffix = ":presence"
+	systemChannel      = "collab:system" // 전체 인스턴스 공지
+	presenceTTL        = 2 * time.Hour   // 비활성 세션 정리 기준과 동일
+	busTimeout         = 2 * time.Second
+)
+
+// 인스턴스 간 전달되는 브로드캐스트 봉투
+type roomEnvelope struct {
+	Origin  string          `json:"origin"`            // 발행한 인스턴스 ID (자기 에코 제거용)
+	Exclude string          `json:"exclude,omitempty"` // 수신에서 제외할 사용자 ID (broadcastToOthers)
+	Payload json.RawMessage `json:"payload"`           // 직렬화된 Message
+}
+
+// Room별 접속자 정보 (Redis Hash 값)
+type presenceEntry struct {
+	UserId      string    `json:"userId"`
+	UserName    string    `json:"userName"`
+	OrgId       string    `json:"orgId"`
+	WorkspaceId string    `json:"workspaceId"`
+	Instance    string    `json:"instance"`
+	JoinedAt    time.Time `json:"joinedAt"`
+}
+
+// RoomBus - Room 단위 Redis Pub/Sub 팬아웃
+// 각 인스턴스는 자신이 가진 Room 채널만 구독하고, 받은 메시지를 로컬 클라이언트에게 전달
+type RoomBus struct {
+	rdb        *redis.Client
+	pubsub     *redis.PubSub
+	instanceId string
+}
+
+// nil이면 단일 인스턴스 모드 (로컬 브로드캐스트만 수행)
+var roomBus *RoomBus
+
+// newRoomBus - RoomBus 생성 및 수신 루프 시작
+func newRoomBus(rdb *redis.Client, instanceId string) *RoomBus {
+	if instanceId == "" {
+		instanceId = uuid.NewString()
+	}
+
+	// 시스템/Job 진행 채널만 구독한 뒤 Room이 생길 때마다 채널 추가
+	bus := &RoomBus{
+		rdb:        rdb,
+		pubsub:     rdb.Subscribe(context.Background(), systemChannel, jobevents.Channel),
+		instanceId: instanceId,
+	}
+	go bus.run()
+
+	log.Printf("✅ [RoomBus] Redis fan-out enabled (instance: %s)", instanceId)
+	return bus
+}
+
+func roomChannel(roomKey string) string {
+	return roomChannelPrefix + roomKey
+}
+
+func roomPresenceKey(roomKey string) string {
+	return roomChannelPrefix + roomKey + roomPresenceSuffix
+}
+
+// subscribe - Room 채널 구독 (세션 생성 시)
+func (b *RoomBus) subscribe(roomKey string) {
+	if b == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), busTimeout)
+	defer cancel()
+
+	if err := b.pubsub.Subscribe(ctx, roomChannel(roomKey)); err != nil {
+		log.Printf("❌ [RoomBus] Failed to subscribe room %s: %v", roomKey, err)
+	}
+}
+
+// unsubscribe - Room 채널 구독 해제 (세션 정리 시)
+func (b *RoomBus) unsubscribe(roomKey string) {
+	if b == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), busTimeout)
+	defer cancel()
+
+	if err := b.pubsub.Unsubscribe(ctx, roomChannel(roomKey)); err != nil {
+		log.Printf("❌ [RoomBus] Failed to unsubscribe room %s: %v", roomKey, err)
+	}
+}
+
+// publish - 다른 인스턴스로 메시지 전파
+func (b *RoomBus) publish(roomKey string, excludeUserId string, payload []byte) {
+	if b == nil {
+		return
+	}
+
+	envelope, err := json.Marshal(roomEnvelope{
+		Origin:  b.instanceId,
+		Exclude: excludeUserId,
+		Payload: payload,
+	})
+	if err != nil {
+		log.Printf("Error marshaling room envelope: %v", err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), busTimeout)
+	defer cancel()
+
+	if err := b.rdb.Publish(ctx, roomChannel(roomKey), envelope).Err(); err != nil {
+		log.Printf("❌ [RoomBus] Publish to room %s failed: %v", roomKey, err)
+	}
+}
+
+// publishSystem - 모든 인스턴스로 시스템 메시지 전파
+func (b *RoomBus) publishSystem(payload []byte) {
+	if b == nil {
+		return
+	}
+
+	envelope, err := json.Marshal(roomEnvelope{Origin: b.instanceId, Payload: payload})
+	if err != nil {
+		log.Printf("Error marshaling system envelope: %v", err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), busTimeout)
+	defer cancel()
+
+	if err := b.rdb.Publish(ctx, systemChannel, envelope).Err(); err != nil {
+		log.Printf("❌ [RoomBus] Publish to %s failed: %v", systemChannel, err)
+	}
+}
+
+// run - 구독 메시지 수신 루프
+func (b *RoomBus) run() {
+	for msg := range b.pubsub.Channel() {
+		// Job 진행 이벤트는 워커가 발행 (봉투 없음, 모든 인스턴스가 각자 로컬 연결에 전달)
+		if msg.Channel == jobevents.Channel {
+			sessionManager.deliverJobProgress([]byte(msg.Payload))
+			continue
+		}
+
+		var envelope roomEnvelope
+		if err := json.Unmarshal([]byte(msg.Payload), &envelope); err != nil {
+			log.Printf("⚠️ [RoomBus] Invalid envelope on %s: %v", msg.Channel, err)
+			continue
+		}
+
+		// 자기 자신이 발행한 메시지는 이미 로컬에 전달됨
+		if envelope.Origin == b.instanceId {
+			continue
+		}
+
+		// 시스템 공지는 이 인스턴스의 모든 Room으로 전달
+		if msg.Channel == systemChannel {
+			sessionManager.deliverToAll(envelope.Payload)
+			continue
+		}
+
+		roomKey := strings.TrimPrefix(msg.Channel, roomChannelPrefix)
+		session := sessionManager.getSession(roomKey)
+		if session == nil {
+			continue
+		}
+		session.handleRemote(envelope)
+	}
+}
+
+// join - Room 접속자 등록 (노드 간 공유)
+func (b *RoomBus) join(roomKey string, client *Client) {
+	if b == nil {
+		return
+	}
+
+	entry, err := json.Marshal(presenceEntry{
+		UserId:      client.userId,
+		UserName:    client.userName,
+		OrgId:       client.orgId,
+		WorkspaceId: client.workspaceId,
+		Instance:    b.instanceId,
+		JoinedAt:    time.Now(),
+	})
+	if err != nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), busTimeout)
+	defer cancel()
+
+	key := roomPresenceKey(roomKey)
+	pipe := b.rdb.TxPipeline()
+	pipe.HSet(ctx, key, client.userId, entry)
+	pipe.Expire(ctx, key, presenceTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Printf("❌ [RoomBus] Failed to register presence %s in %s: %v", client.userId, roomKey, err)
+	}
+}
+
+// leave - Room 접속자 제거
+func (b *RoomBus) leave(roomKey string, userId string) {
+	if b == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), busTimeout)
+	defer cancel()
+
+	if err := b.rdb.HDel(ctx, roomPresenceKey(roomKey), userId).Err(); err != nil {
+		log.Printf("❌ [RoomBus] Failed to remove presence %s in %s: %v", userId, roomKey, err)
+	}
+}
+
+// participants - 전체 인스턴스 기준 Room 접속자 목록
+func (b *RoomBus) participants(roomKey string) []presenceEntry {
+	if b == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), busTimeout)
+	defer cancel()
+
+	values, err := b.rdb.HGetAll(ctx, roomPresenceKey(roomKey)).Result()
+	if err != nil {
+		log.Printf("❌ [RoomBus] Failed to load presence for %s: %v", roomKey, err)
+		return nil
+	}
+
+	entries := make([]presenceEntry, 0, len(values))
+	for _, value := range values {
+		var entry presenceEntry
+		if err := json.Unmarshal([]byte(value), &entry); err == nil {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
@@ -0,0 +1,438 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	supa "github.com/supabase-community/supabase-go"
+
+	"quel-canvas-server/modules/common/config"
+)
+
+// 코멘트 저장 위치
+const (
+	commentThreadTable = "quel_canvas_comment_threads"
+	commentTable       = "quel_canvas_comments"
+	commentBodyLimit   = 4000 // 코멘트 본문 최대 길이 (문자)
+)
+
+// 스레드 상태
+const (
+	threadOpen     = "open"
+	threadResolved = "resolved"
+)
+
+// quel_canvas_comment_threads 테이블 행 (캔버스 아이템 또는 좌표에 고정)
+type commentThread struct {
+	ThreadId      string     `json:"thread_id"`
+	OrgId         string     `json:"org_id"`
+	WorkspaceId   string     `json:"workspace_id"`
+	ItemId        *string    `json:"item_id"`
+	X             *float64   `json:"x"`
+	Y             *float64   `json:"y"`
+	Status        string     `json:"status"` // open | resolved
+	CreatedBy     string     `json:"created_by"`
+	CreatedByName string     `json:"created_by_name"`
+	CreatedAt     time.Time  `json:"created_at"`
+	ResolvedBy    *string    `json:"resolved_by"`
+	ResolvedAt    *time.Time `json:"resolved_at"`
+}
+
+// quel_canvas_comments 테이블 행
+type canvasComment struct {
+	CommentId string    `json:"comment_id"`
+	ThreadId  string    `json:"thread_id"`
+	UserId    string    `json:"user_id"`
+	UserName  string    `json:"user_name"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// 스레드 + 코멘트 (브로드캐스트/REST 응답용)
+type commentThreadView struct {
+	commentThread
+	Comments []canvasComment `json:"comments"`
+}
+
+// CommentStore - 코멘트 스레드 영속화 (Supabase)
+type CommentStore struct {
+	supabase *supa.Client
+}
+
+// nil이면 코멘트 기능 비활성화
+var commentStore *CommentStore
+
+// newCommentStore - Supabase 설정이 없으면 nil
+func newCommentStore(cfg *config.Config) *CommentStore {
+	if cfg.SupabaseURL == "" || cfg.SupabaseServiceKey == "" {
+		log.Println("⚠️ [Comments] Supabase not configured - canvas comments disabled")
+		return nil
+	}
+
+	supabaseClient, err := supa.NewClient(cfg.SupabaseURL, cfg.SupabaseServiceKey, nil)
+	if err != nil {
+		log.Printf("❌ [Comments] Failed to create Supabase client: %v", err)
+		return nil
+	}
+
+	log.Println("✅ [Comments] Canvas comment threads enabled")
+	return &CommentStore{supabase: supabaseClient}
+}
+
+// createThread - 스레드와 첫 코멘트 저장
+func (st *CommentStore) createThread(thread commentThread, first canvasComment) error {
+	if _, _, err := st.supabase.From(commentThreadTable).
+		Insert(thread, false, "", "minimal", "").
+		Execute(); err != nil {
+		return fmt.Errorf("failed to insert comment thread: %w", err)
+	}
+	if err := st.addComment(first); err != nil {
+		// 첫 코멘트 없는 빈 스레드가 남지 않도록 삭제
+		if _, _, delErr := st.supabase.From(commentThreadTable).
+			Delete("minimal", "").
+			Eq("thread_id", thread.ThreadId).
+			Execute(); delErr != nil {
+			log.Printf("⚠️ [Comments] Failed to remove thread %s after comment insert failed: %v", thread.ThreadId, delErr)
+		}
+		return err
+	}
+	return nil
+}
+
+// addComment - 코멘트 저장
+func (st *CommentStore) addComment(comment canvasComment) error {
+	if _, _, err := st.supabase.From(commentTable).
+		Insert(comment, false, "", "minimal", "").
+		Execute(); err != nil {
+		return fmt.Errorf("failed to insert comment: %w", err)
+	}
+	return nil
+}
+
+// getThread - Room(org/workspace)에 속한 스레드 조회 (없으면 nil)
+func (st *CommentStore) getThread(orgId string, workspaceId string, threadId string) (*commentThread, error) {
+	var threads []commentThread
+	_, err := st.supabase.From(commentThreadTable).
+		Select("*", "", false).
+		Eq("thread_id", threadId).
+		Eq("org_id", orgId).
+		Eq("workspace_id", workspaceId).
+		ExecuteTo(&threads)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read comment thread: %w", err)
+	}
+	if len(threads) == 0 {
+		return nil, nil
+	}
+	return &threads[0], nil
+}
+
+// resolveThread - 스레드 해결 처리
+func (st *CommentStore) resolveThread(threadId string, userId string, resolvedAt time.Time) error {
+	_, _, err := st.supabase.From(commentThreadTable).
+		Update(map[string]interface{}{
+			"status":      threadResolved,
+			"resolved_by": userId,
+			"resolved_at": resolvedAt,
+		}, "minimal", "").
+		Eq("thread_id", threadId).
+		Execute()
+	if err != nil {
+		return fmt.Errorf("failed to resolve comment thread: %w", err)
+	}
+	return nil
+}
+
+// listThreads - 워크스페이스의 스레드와 코멘트 목록 (생성 순)
+func (st *CommentStore) listThreads(orgId string, workspaceId string, status string) ([]commentThreadView, error) {
+	var threads []commentThread
+	_, err := st.supabase.From(commentThreadTable).
+		Select("*", "", false).
+		Eq("org_id", orgId).
+		Eq("workspace_id", workspaceId).
+		Eq("status", status).
+		ExecuteTo(&threads)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list comment threads: %w", err)
+	}
+
+	views := make([]commentThreadView, 0, len(threads))
+	if len(threads) == 0 {
+		return views, nil
+	}
+	sort.Slice(threads, func(i, j int) bool { return threads[i].CreatedAt.Before(threads[j].CreatedAt) })
+
+	threadIds := make([]string, len(threads))
+	for i, thread := range threads {
+		threadIds[i] = thread.ThreadId
+	}
+
+	var comments []canvasComment
+	_, err = st.supabase.From(commentTable).
+		Select("*", "", false).
+		In("thread_id", threadIds).
+		ExecuteTo(&comments)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list comments: %w", err)
+	}
+
+	sort.Slice(comments, func(i, j int) bool { return comments[i].CreatedAt.Before(comments[j].CreatedAt) })
+	byThread := make(map[string][]canvasComment, len(threads))
+	for _, comment := range comments {
+		byThread[comment.ThreadId] = append(byThread[comment.ThreadId], comment)
+	}
+	for _, thread := range threads {
+		comments := byThread[thread.ThreadId]
+		if comments == nil {
+			comments = []canvasComment{}
+		}
+		views = append(views, commentThreadView{commentThread: thread, Comments: comments})
+	}
+	return views, nil
+}
+
+// commentBody - 본문 검증 (공백 제거 후 비어있거나 너무 길면 에러)
+func commentBody(data map[string]interface{}) (string, error) {
+	body, _ := data["body"].(string)
+	body = strings.TrimSpace(body)
+	if body == "" {
+		return "", fmt.Errorf("missing body")
+	}
+	if len([]rune(body)) > commentBodyLimit {
+		return "", fmt.Errorf("body too long")
+	}
+	return body, nil
+}
+
+// handleComment - comment_create / comment_reply / comment_resolve 처리 후 Room에 브로드캐스트
+func (s *Session) handleComment(c *Client, message Message) {
+	if commentStore == nil {
+		c.sendMessage(Message{
+			Type:   "error",
+			Reason: "comments disabled",
+			Data:   map[string]interface{}{"messageType": message.Type},
+		})
+		return
+	}
+
+	var (
+		broadcast Message
+		err       error
+	)
+	switch message.Type {
+	case "comment_create":
+		broadcast, err = s.createCommentThread(c, message)
+	case "comment_reply":
+		broadcast, err = s.replyCommentThread(c, message)
+	case "comment_resolve":
+		broadcast, err = s.resolveCommentThread(c, message)
+	}
+	if err != nil {
+		log.Printf("❌ [Comments] %s from %s in room %s failed: %v", message.Type, c.userId, s.id, err)
+		c.sendMessage(Message{
+			Type:   "error",
+			Reason: err.Error(),
+			Data:   map[string]interface{}{"messageType": message.Type},
+		})
+		return
+	}
+
+	broadcast.UserId = c.userId
+	broadcast.UserName = c.userName
+	broadcast.OrgId = c.orgId
+	broadcast.WorkspaceId = c.workspaceId
+	s.broadcastToAll(broadcast)
+}
+
+func (s *Session) createCommentThread(c *Client, message Message) (Message, error) {
+	body, err := commentBody(message.Data)
+	if err != nil {
+		return Message{}, err
+	}
+
+	now := time.Now().UTC()
+	thread := commentThread{
+		ThreadId:      uuid.NewString(),
+		OrgId:         c.orgId,
+		WorkspaceId:   c.workspaceId,
+		Status:        threadOpen,
+		CreatedBy:     c.userId,
+		CreatedByName: c.userName,
+		CreatedAt:     now,
+	}
+
+	// 아이템 ID 또는 캔버스 좌표에 고정
+	if message.ItemId != "" {
+		itemId := message.ItemId
+		thread.ItemId = &itemId
+	}
+	x, hasX := message.Data["x"].(float64)
+	y, hasY := message.Data["y"].(float64)
+	if hasX && hasY {
+		thread.X, thread.Y = &x, &y
+	}
+	if thread.ItemId == nil && thread.X == nil {
+		return Message{}, fmt.Errorf("missing anchor (itemId or x/y)")
+	}
+
+	first := canvasComment{
+		CommentId: uuid.NewString(),
+		ThreadId:  thread.ThreadId,
+		UserId:    c.userId,
+		UserName:  c.userName,
+		Body:      body,
+		CreatedAt: now,
+	}
+	if err := commentStore.createThread(thread, first); err != nil {
+		return Message{}, err
+	}
+
+	log.Printf("💬 [Comments] %s opened thread %s in room %s", c.userId, thread.ThreadId, s.id)
+	return Message{
+		Type:   "comment-thread-created",
+		ItemId: message.ItemId,
+		Data: map[string]interface{}{
+			"thread": commentThreadView{commentThread: thread, Comments: []canvasComment{first}},
+		},
+	}, nil
+}
+
+func (s *Session) replyCommentThread(c *Client, message Message) (Message, error) {
+	threadId, _ := message.Data["threadId"].(string)
+	if threadId == "" {
+		return Message{}, fmt.Errorf("missing threadId")
+	}
+	body, err := commentBody(message.Data)
+	if err != nil {
+		return Message{}, err
+	}
+
+	thread, err := commentStore.getThread(c.orgId, c.workspaceId, threadId)
+	if err != nil {
+		return Message{}, err
+	}
+	if thread == nil {
+		return Message{}, fmt.Errorf("thread not found")
+	}
+	if thread.Status != threadOpen {
+		return Message{}, fmt.Errorf("thread resolved")
+	}
+
+	comment := canvasComment{
+		CommentId: uuid.NewString(),
+		ThreadId:  threadId,
+		UserId:    c.userId,
+		UserName:  c.userName,
+		Body:      body,
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := commentStore.addComment(comment); err != nil {
+		return Message{}, err
+	}
+
+	return Message{
+		Type: "comment-added",
+		Data: map[string]interface{}{"threadId": threadId, "comment": comment},
+	}, nil
+}
+
+func (s *Session) resolveCommentThread(c *Client, message Message) (Message, error) {
+	threadId, _ := message.Data["threadId"].(string)
+	if threadId == "" {
+		return Message{}, fmt.Errorf("missing threadId")
+	}
+
+	thread, err := commentStore.getThread(c.orgId, c.workspaceId, threadId)
+	if err != nil {
+		return Message{}, err
+	}
+	if thread == nil {
+		return Message{}, fmt.Errorf("thread not found")
+	}
+	// viewer(리뷰어)는 본인이 연 스레드만 해결 가능
+	if c.getRole() == roleViewer && thread.CreatedBy != c.userId {
+		return Message{}, fmt.Errorf("forbidden")
+	}
+	if thread.Status == threadResolved {
+		return Message{}, fmt.Errorf("thread already resolved")
+	}
+
+	resolvedAt := time.Now().UTC()
+	if err := commentStore.resolveThread(threadId, c.userId, resolvedAt); err != nil {
+		return Message{}, err
+	}
+
+	log.Printf("✅ [Comments] %s resolved thread %s in room %s", c.userId, threadId, s.id)
+	return Message{
+		Type: "comment-thread-resolved",
+		Data: map[string]interface{}{
+			"threadId":   threadId,
+			"resolvedBy": c.userId,
+			"resolvedAt": resolvedAt,
+		},
+	}, nil
+}
+
+// authorizeRoomRequest - REST/재생 요청 인증 (WebSocket과 같은 Bearer 토큰 + 조직 멤버십, WS_AUTH_DISABLED=true면 생략)
+func authorizeRoomRequest(w http.ResponseWriter, r *http.Request, roomKey string) bool {
+	if wsAuth == nil {
+		return true
+	}
+	orgId, workspaceId, _ := strings.Cut(roomKey, ":")
+	if _, status, err := wsAuth.authenticate(r, orgId, workspaceId); err != nil {
+		http.Error(w, fmt.Sprintf(`{"error": %q}`, err.Error()), status)
+		return false
+	}
+	return true
+}
+
+// registerCommentRoutes - 코멘트 REST 라우트 등록
+func registerCommentRoutes(r *mux.Router) {
+	r.HandleFunc("/api/workspaces/{orgId}/{workspaceId}/comments", listCommentThreads).Methods("GET")
+}
+
+// GET /api/workspaces/{orgId}/{workspaceId}/comments?status=open|resolved (기본값: open)
+func listCommentThreads(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	vars := mux.Vars(r)
+	orgId, workspaceId := vars["orgId"], vars["workspaceId"]
+
+	if commentStore == nil {
+		http.Error(w, `{"error": "comments disabled"}`, http.StatusServiceUnavailable)
+		return
+	}
+
+	if !authorizeRoomRequest(w, r, orgId+":"+workspaceId) {
+		return
+	}
+
+	status := r.URL.Query().Get("status")
+	if status == "" {
+		status = threadOpen
+	}
+	if status != threadOpen && status != threadResolved {
+		http.Error(w, `{"error": "status must be open or resolved"}`, http.StatusBadRequest)
+		return
+	}
+
+	threads, err := commentStore.listThreads(orgId, workspaceId, status)
+	if err != nil {
+		log.Printf("❌ [Comments] Failed to list threads for %s:%s: %v", orgId, workspaceId, err)
+		http.Error(w, `{"error": "failed to list comment threads"}`, http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"orgId":       orgId,
+		"workspaceId": workspaceId,
+		"status":      status,
+		"threads":     threads,
+	})
+}
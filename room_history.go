@@ -0,0 +1,242 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// 사용자별 되돌리기 기록 최대 개수
+const historyLimit = 100
+
+// elementChange - 노드/엣지 하나의 변경 전후 (nil이면 존재하지 않음)
+// 그래프 요소는 copy-on-write로만 바뀌므로 참조를 그대로 보관해도 안전
+type elementChange struct {
+	Target string
+	Id     string
+	Before map[string]interface{}
+	After  map[string]interface{}
+}
+
+// historyEntry - 사용자 작업 1회 (sync-nodes 또는 patch-nodes)
+type historyEntry struct {
+	changes []elementChange
+}
+
+// roomHistory - Room 작업 기록 (사용자별 undo/redo 스택)
+type roomHistory struct {
+	undo map[string][]historyEntry
+	redo map[string][]historyEntry
+}
+
+func newRoomHistory() *roomHistory {
+	return &roomHistory{
+		undo: make(map[string][]historyEntry),
+		redo: make(map[string][]historyEntry),
+	}
+}
+
+// historyConflictError - 되돌릴 요소가 모두 다른 사용자에 의해 바뀐 경우
+type historyConflictError struct {
+	reason    string
+	conflicts []string
+}
+
+func (e *historyConflictError) Error() string {
+	return e.reason
+}
+
+func pushHistory(stack []historyEntry, entry historyEntry) []historyEntry {
+	stack = append(stack, entry)
+	if len(stack) > historyLimit {
+		stack = append([]historyEntry(nil), stack[len(stack)-historyLimit:]...)
+	}
+	return stack
+}
+
+// recordHistoryLocked - 사용자 작업 기록 (s.mutex 보유 상태에서 호출)
+func (s *Session) recordHistoryLocked(userId string, changes []elementChange) {
+	if len(changes) == 0 {
+		return
+	}
+	if s.history == nil {
+		s.history = newRoomHistory()
+	}
+	s.history.undo[userId] = pushHistory(s.history.undo[userId], historyEntry{changes: changes})
+	delete(s.history.redo, userId)
+}
+
+// historyDepth - 사용자의 undo/redo 가능 횟수
+func (s *Session) historyDepth(userId string) (int, int) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	if s.history == nil {
+		return 0, 0
+	}
+	return len(s.history.undo[userId]), len(s.history.redo[userId])
+}
+
+// undoRedo - 사용자의 마지막 작업을 되돌리거나(redo=false) 다시 실행
+// 현재 그래프 기준으로 역연산을 계산하며, 그 사이 다른 사용자가 바꾼 요소는 건너뜀
+func (s *Session) undoRedo(userId string, redo bool) (int64, []graphOp, []string, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.history == nil {
+		s.history = newRoomHistory()
+	}
+	from, to := s.history.undo, s.history.redo
+	if redo {
+		from, to = s.history.redo, s.history.undo
+	}
+
+	stack := from[userId]
+	if len(stack) == 0 {
+		return s.revision, nil, nil, &historyConflictError{reason: "nothing to " + undoRedoName(redo)}
+	}
+	// 적용에 성공한 뒤에만 스택에서 제거 (충돌/revision 실패 시 다시 시도할 수 있도록 유지)
+	entry := stack[len(stack)-1]
+
+	nodeIndex := indexElements(s.nodes)
+	edgeIndex := indexElements(s.edges)
+
+	var ops []graphOp
+	var conflicts []string
+	for i := len(entry.changes) - 1; i >= 0; i-- {
+		change := entry.changes[i]
+		current := nodeIndex[change.Id]
+		if change.Target == targetEdge {
+			current = edgeIndex[change.Id]
+		}
+
+		// 기록 이후 다른 사용자가 바꾼 요소는 되돌리지 않음
+		if !reflect.DeepEqual(current, change.After) {
+			conflicts = append(conflicts, elementKey(change.Target, change.Id))
+			continue
+		}
+		if op, ok := inverseOp(change); ok {
+			ops = append(ops, op)
+		}
+	}
+
+	if len(ops) == 0 {
+		return s.revision, nil, conflicts, &historyConflictError{
+			reason:    fmt.Sprintf("%s conflicts with newer changes", undoRedoName(redo)),
+			conflicts: conflicts,
+		}
+	}
+
+	revision, applied, changes, err := s.applyOpsLocked(userId, ops)
+	if err != nil {
+		return revision, nil, conflicts, err
+	}
+	from[userId] = stack[:len(stack)-1]
+
+	// 적용된 변경은 반대쪽 스택으로 이동 (undo → redo, redo → undo)
+	if len(changes) > 0 {
+		to[userId] = pushHistory(to[userId], historyEntry{changes: changes})
+	}
+	return revision, applied, conflicts, nil
+}
+
+func undoRedoName(redo bool) string {
+	if redo {
+		return "redo"
+	}
+	return "undo"
+}
+
+// inverseOp - 변경 후(After) 상태를 변경 전(Before) 상태로 되돌리는 연산
+func inverseOp(change elementChange) (graphOp, bool) {
+	switch {
+	case change.Before == nil && change.After == nil:
+		return graphOp{}, false
+	case change.Before == nil:
+		return graphOp{Op: opRemove, Target: change.Target, Id: change.Id}, true
+	case change.After == nil:
+		return graphOp{Op: opAdd, Target: change.Target, Id: change.Id, Value: change.Before}, true
+	default:
+		return graphOp{Op: opUpdate, Target: change.Target, Id: change.Id, Value: createMergePatch(change.After, change.Before)}, true
+	}
+}
+
+// createMergePatch - from을 to로 바꾸는 JSON Merge Patch 생성
+func createMergePatch(from map[string]interface{}, to map[string]interface{}) map[string]interface{} {
+	patch := make(map[string]interface{})
+	for k := range from {
+		if _, ok := to[k]; !ok {
+			patch[k] = nil
+		}
+	}
+	for k, toValue := range to {
+		fromValue, exists := from[k]
+		if exists && reflect.DeepEqual(fromValue, toValue) {
+			continue
+		}
+		fromMap, fromIsMap := fromValue.(map[string]interface{})
+		toMap, toIsMap := toValue.(map[string]interface{})
+		if exists && fromIsMap && toIsMap {
+			patch[k] = createMergePatch(fromMap, toMap)
+			continue
+		}
+		patch[k] = deepCopyJSON(toValue)
+	}
+	return patch
+}
+
+// indexElements - id → 요소 맵
+func indexElements(elements []interface{}) map[string]map[string]interface{} {
+	index := make(map[string]map[string]interface{}, len(elements))
+	for _, element := range elements {
+		if m, ok := element.(map[string]interface{}); ok {
+			if id, ok := m["id"].(string); ok {
+				index[id] = m
+			}
+		}
+	}
+	return index
+}
+
+// diffGraph - 전체 그래프 비교로 변경 내역 생성 (sync-nodes)
+func diffGraph(target string, before []interface{}, after []interface{}) []elementChange {
+	beforeIndex := indexElements(before)
+	afterIndex := indexElements(after)
+
+	var changes []elementChange
+	for id, beforeElement := range beforeIndex {
+		afterElement := afterIndex[id]
+		if !reflect.DeepEqual(beforeElement, afterElement) {
+			changes = append(changes, elementChange{Target: target, Id: id, Before: beforeElement, After: afterElement})
+		}
+	}
+	for id, afterElement := range afterIndex {
+		if _, existed := beforeIndex[id]; !existed {
+			changes = append(changes, elementChange{Target: target, Id: id, After: afterElement})
+		}
+	}
+	return changes
+}
+
+// diffTouched - 적용된 연산이 건드린 요소만 비교 (patch-nodes)
+func diffTouched(beforeNodes, afterNodes, beforeEdges, afterEdges []interface{}, applied []graphOp) []elementChange {
+	beforeNodeIndex := indexElements(beforeNodes)
+	afterNodeIndex := indexElements(afterNodes)
+	beforeEdgeIndex := indexElements(beforeEdges)
+	afterEdgeIndex := indexElements(afterEdges)
+
+	seen := make(map[string]bool, len(applied))
+	var changes []elementChange
+	for _, op := range applied {
+		key := elementKey(op.Target, op.Id)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		before, after := beforeNodeIndex[op.Id], afterNodeIndex[op.Id]
+		if op.Target == targetEdge {
+			before, after = beforeEdgeIndex[op.Id], afterEdgeIndex[op.Id]
+		}
+		changes = append(changes, elementChange{Target: op.Target, Id: op.Id, Before: before, After: after})
+	}
+	return changes
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"log"
+	"time"
+)
+
+// 로컬 전달 대기열 크기 (브로드캐스트 호출자가 hub를 기다리지 않도록)
+const hubOutboundBuffer = 256
+
+// Session hub - 세션마다 하나의 고루틴이 clients 맵 변경과 클라이언트 대기열 close를 전담
+// 다른 고루틴은 s.mutex.RLock으로 읽기만 하고, 변경은 register/unregister/stop 요청으로 처리
+
+type hubRegister struct {
+	client *Client
+	result chan int // 등록 후 접속자 수
+}
+
+type hubUnregister struct {
+	client *Client
+	result chan hubLeave
+}
+
+// hubLeave - unregister 결과
+type hubLeave struct {
+	removed       bool     // 현재 등록된 클라이언트였는지 (재접속으로 교체된 이전 연결이면 false)
+	remaining     int      // 남은 접속자 수
+	releasedLocks []string // 해제된 아이템 잠금
+}
+
+type hubOutbound struct {
+	exclude     string
+	coalesceKey string // 커서/선택 메시지는 클라이언트 대기열에서 최신 값으로 대체
+	payload     []byte
+	disconnect  string // 설정되면 전달 대신 이 사용자의 연결 종료 (앞선 메시지를 보낸 뒤)
+
+	followersOnly bool // follow 구독 중인 클라이언트에게만 전달 (viewport-update)
+}
+
+type hubStop struct {
+	force  bool      // false면 접속자가 없을 때만 종료
+	result chan bool // 종료 여부
+}
+
+// newSession - 세션 생성 및 hub 고루틴 시작
+func newSession(id string) *Session {
+	now := time.Now()
+	s := &Session{
+		id:           id,
+		clients:      make(map[string]*Client),
+		createdAt:    now,
+		lastActivity: now,
+		register:     make(chan hubRegister),
+		unregister:   make(chan hubUnregister),
+		outbound:     make(chan hubOutbound, hubOutboundBuffer),
+		stopRequest:  make(chan hubStop),
+		stopped:      make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+// run - hub 이벤트 루프 (stop 요청 전까지)
+func (s *Session) run() {
+	presenceTicker := time.NewTicker(presenceTickInterval)
+	defer presenceTicker.Stop()
+
+	for {
+		select {
+		case <-presenceTicker.C:
+			s.flushPresence()
+
+		case req := <-s.register:
+			req.result <- s.handleRegister(req.client)
+
+		case req := <-s.unregister:
+			req.result <- s.handleUnregister(req.client)
+
+		case out := <-s.outbound:
+			s.handleOutbound(out)
+
+		case req := <-s.stopRequest:
+			if !req.force && s.clientCount() > 0 {
+				req.result <- false
+				continue
+			}
+			s.handleStop()
+			req.result <- true
+			return
+		}
+	}
+}
+
+func (s *Session) handleRegister(client *Client) int {
+	s.mutex.Lock()
+	previous := s.clients[client.userId]
+	s.clients[client.userId] = client
+	s.lastActivity = time.Now()
+	count := len(s.clients)
+	s.mutex.Unlock()
+
+	// 같은 사용자가 다시 접속하면 이전 연결 종료
+	if previous != nil && previous != client {
+		log.Printf("🔁 [Hub] Replacing previous connection of %s in room %s", client.userId, s.id)
+		previous.closeSend()
+	}
+	return count
+}
+
+func (s *Session) handleUnregister(client *Client) hubLeave {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.clients[client.userId] != client {
+		client.closeSend()
+		return hubLeave{remaining: len(s.clients)}
+	}
+	delete(s.clients, client.userId)
+	delete(s.followers, client.userId)
+	s.lastActivity = time.Now()
+	client.closeSend()
+	return hubLeave{
+		removed:       true,
+		remaining:     len(s.clients),
+		releasedLocks: s.releaseLocksLocked(client.userId),
+	}
+}
+
+func (s *Session) handleOutbound(out hubOutbound) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if out.disconnect != "" {
+		if client := s.clients[out.disconnect]; client != nil {
+			// 대기열이 닫히면 writePump가 남은 메시지를 보내고 연결을 닫음 → readPump에서 unregister
+			client.closeSend()
+		}
+		return
+	}
+
+	for userId, client := range s.clients {
+		if userId == out.exclude || (out.followersOnly && !s.followers[userId]) {
+			continue
+		}
+		// 느린 클라이언트도 끊지 않음 (대기열에서 합치거나 resync-required로 대체)
+		client.enqueue(out.payload, out.coalesceKey)
+	}
+}
+
+func (s *Session) handleStop() {
+	// 종료 전에 요청된 메시지(room-closed 등)는 전달
+	for {
+		select {
+		case out := <-s.outbound:
+			s.handleOutbound(out)
+			continue
+		default:
+		}
+		break
+	}
+
+	s.mutex.Lock()
+	for userId, client := range s.clients {
+		client.closeSend()
+		delete(s.clients, userId)
+	}
+	s.mutex.Unlock()
+	close(s.stopped)
+}
+
+func (s *Session) clientCount() int {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return len(s.clients)
+}
+
+// join - hub에 클라이언트 등록 (세션이 이미 종료됐으면 false)
+func (s *Session) join(client *Client) (int, bool) {
+	req := hubRegister{client: client, result: make(chan int, 1)}
+	select {
+	case s.register <- req:
+		return <-req.result, true
+	case <-s.stopped:
+		return 0, false
+	}
+}
+
+// leave - hub에서 클라이언트 제거
+func (s *Session) leave(client *Client) hubLeave {
+	req := hubUnregister{client: client, result: make(chan hubLeave, 1)}
+	select {
+	case s.unregister <- req:
+		return <-req.result
+	case <-s.stopped:
+		// 종료 시 hub가 이미 모든 대기열을 닫음
+		return hubLeave{}
+	}
+}
+
+// stop - hub 종료 (force=false면 접속자가 없을 때만)
+func (s *Session) stop(force bool) bool {
+	req := hubStop{force: force, result: make(chan bool, 1)}
+	select {
+	case s.stopRequest <- req:
+		return <-req.result
+	case <-s.stopped:
+		return true
+	}
+}
+
+// disconnectLocal - 앞서 요청한 메시지를 전달한 뒤 사용자 연결 종료 (이 인스턴스에 있을 때만)
+func (s *Session) disconnectLocal(userId string) {
+	select {
+	case s.outbound <- hubOutbound{disconnect: userId}:
+	case <-s.stopped:
+	}
+}
+
+// deliverLocal - 이 인스턴스에 연결된 클라이언트에게 전달 (excludeUserId는 제외)
+func (s *Session) deliverLocal(excludeUserId string, coalesceKey string, messageBytes []byte) {
+	select {
+	case s.outbound <- hubOutbound{exclude: excludeUserId, coalesceKey: coalesceKey, payload: messageBytes}:
+	case <-s.stopped:
+	}
+}
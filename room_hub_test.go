@@ -0,0 +1,266 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	// 세션 로그가 많아 테스트 출력에서는 숨김
+	log.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+// newTestClient - WebSocket 없이 전송 대기열만 가진 클라이언트
+func newTestClient(userId string) *Client {
+	return &Client{
+		orgId:       "org",
+		workspaceId: "workspace",
+		userId:      userId,
+		userName:    userId,
+		outbox:      newClientOutbox(),
+		role:        roleEditor,
+	}
+}
+
+// drain - writePump처럼 대기열이 닫힐 때까지 비움
+func drain(c *Client, wg *sync.WaitGroup) {
+	defer wg.Done()
+	for range c.outbox.ready {
+		if _, closed := c.outbox.take(); closed {
+			return
+		}
+	}
+}
+
+// waitFor - hub가 비동기로 처리하므로 조건이 맞을 때까지 대기
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func isClosed(c *Client) bool {
+	_, closed := c.outbox.take()
+	return closed
+}
+
+func waitClosed(t *testing.T, c *Client) {
+	t.Helper()
+	waitFor(t, "outbox of "+c.userId+" to close", func() bool { return isClosed(c) })
+}
+
+func TestSessionHubConcurrentJoinLeaveBroadcast(t *testing.T) {
+	s := newSession("org:hammer")
+	defer s.stop(true)
+
+	var drainers sync.WaitGroup
+	var workers sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		workers.Add(1)
+		go func(i int) {
+			defer workers.Done()
+			for j := 0; j < 20; j++ {
+				// 같은 사용자 ID 재접속도 섞음
+				c := newTestClient(fmt.Sprintf("user-%d", (i+j)%30))
+				drainers.Add(1)
+				go drain(c, &drainers)
+
+				if !s.addClient(c) {
+					t.Errorf("addClient failed on running session")
+					c.closeSend()
+					return
+				}
+				s.broadcastToOthers(c.userId, Message{Type: "item_position_update", UserId: c.userId})
+				s.broadcastToAll(Message{Type: "cursor-update", UserId: c.userId})
+				c.sendMessage(Message{Type: "patch-ack"})
+				s.removeClient(c)
+				s.removeClient(c) // 중복 제거는 무시
+			}
+		}(i)
+	}
+	workers.Wait()
+
+	if n := s.clientCount(); n != 0 {
+		t.Fatalf("expected empty session, got %d clients", n)
+	}
+	drainers.Wait()
+}
+
+func TestSessionHubStopClosesEveryClientOnce(t *testing.T) {
+	s := newSession("org:stop")
+
+	clients := make([]*Client, 20)
+	for i := range clients {
+		clients[i] = newTestClient(fmt.Sprintf("user-%d", i))
+		if !s.addClient(clients[i]) {
+			t.Fatalf("addClient failed")
+		}
+	}
+
+	var wg sync.WaitGroup
+	for _, c := range clients {
+		wg.Add(2)
+		go func(c *Client) {
+			defer wg.Done()
+			s.removeClient(c)
+		}(c)
+		go func(c *Client) {
+			defer wg.Done()
+			s.broadcastToAll(Message{Type: "nodes-updated", UserId: c.userId})
+		}(c)
+	}
+	if !s.stop(true) {
+		t.Fatalf("forced stop returned false")
+	}
+	wg.Wait()
+
+	for _, c := range clients {
+		waitClosed(t, c)
+		if c.closeSend() {
+			t.Fatalf("outbox of %s closed twice", c.userId)
+		}
+	}
+	if s.addClient(newTestClient("late")) {
+		t.Fatalf("addClient succeeded on stopped session")
+	}
+}
+
+func TestSessionHubStopIfEmpty(t *testing.T) {
+	s := newSession("org:empty")
+	c := newTestClient("user")
+	if !s.addClient(c) {
+		t.Fatalf("addClient failed")
+	}
+	if s.stop(false) {
+		t.Fatalf("stop(false) stopped a session with clients")
+	}
+	s.removeClient(c)
+	if !s.stop(false) {
+		t.Fatalf("stop(false) did not stop an empty session")
+	}
+}
+
+func TestSessionHubReplacesDuplicateUser(t *testing.T) {
+	s := newSession("org:duplicate")
+	defer s.stop(true)
+
+	first := newTestClient("user")
+	second := newTestClient("user")
+	s.addClient(first)
+	s.addClient(second)
+
+	waitClosed(t, first)
+	if s.getClient("user") != second {
+		t.Fatalf("expected second connection to be registered")
+	}
+
+	// 이전 연결의 readPump 종료가 새 연결을 제거하면 안 됨
+	s.removeClient(first)
+	if s.getClient("user") != second {
+		t.Fatalf("removing replaced connection removed the new one")
+	}
+}
+
+func TestSessionHubSlowConsumerGetsResyncRequired(t *testing.T) {
+	s := newSession("org:slow")
+	defer s.stop(true)
+
+	// 대기열을 비우지 않는 클라이언트
+	slow := newTestClient("slow")
+	s.addClient(slow)
+	for i := 0; i < outboxResyncThreshold+10; i++ {
+		s.broadcastToAll(Message{Type: "nodes-updated"})
+	}
+
+	// 대기열이 resync-required 하나로 대체된 뒤 이후 메시지가 이어짐
+	waitFor(t, "resync-required", func() bool {
+		slow.outbox.mutex.Lock()
+		defer slow.outbox.mutex.Unlock()
+		return len(slow.outbox.frames) > 0 && string(slow.outbox.frames[0].payload) == string(resyncRequiredPayload)
+	})
+	if s.getClient("slow") != slow {
+		t.Fatalf("slow client was disconnected")
+	}
+}
+
+func TestSessionPresenceBatchExcludesOwnUpdates(t *testing.T) {
+	s := newSession("org:presence")
+	defer s.stop(true)
+
+	alice := newTestClient("alice")
+	bob := newTestClient("bob")
+	s.addClient(alice)
+	s.addClient(bob)
+
+	for i := 0; i < 100; i++ {
+		s.queuePresence(Message{Type: "cursor-update", UserId: "alice", CursorX: float64(i)}, false)
+	}
+
+	var batch Message
+	waitFor(t, "presence-batch for bob", func() bool {
+		payloads, _ := bob.outbox.take()
+		for _, payload := range payloads {
+			var message Message
+			json.Unmarshal(payload, &message)
+			if message.Type == "presence-batch" {
+				batch = message
+				return true
+			}
+		}
+		return false
+	})
+	if len(batch.Updates) != 1 || batch.Updates[0].CursorX != 99 {
+		t.Fatalf("expected one merged alice cursor (99), got %+v", batch.Updates)
+	}
+
+	payloads, _ := alice.outbox.take()
+	for _, payload := range payloads {
+		var message Message
+		json.Unmarshal(payload, &message)
+		if message.Type == "presence-batch" {
+			t.Fatalf("alice received her own cursor")
+		}
+	}
+}
+
+func TestSessionKickDeliversMessageBeforeDisconnect(t *testing.T) {
+	s := newSession("org:kick")
+	defer s.stop(true)
+
+	target := newTestClient("target")
+	other := newTestClient("other")
+	s.addClient(target)
+	s.addClient(other)
+
+	s.broadcastToAll(Message{Type: "user-kicked", TargetUserId: "target", Reason: "spam"})
+	s.disconnectLocal("target")
+
+	var received []string
+	waitFor(t, "target outbox to close", func() bool {
+		payloads, closed := target.outbox.take()
+		for _, payload := range payloads {
+			var message Message
+			json.Unmarshal(payload, &message)
+			received = append(received, message.Type)
+		}
+		return closed
+	})
+	if len(received) == 0 || received[len(received)-1] != "user-kicked" {
+		t.Fatalf("expected user-kicked as last frame before disconnect, got %v", received)
+	}
+	if isClosed(other) {
+		t.Fatalf("other client was disconnected")
+	}
+}
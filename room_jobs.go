@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+
+	"quel-canvas-server/modules/common/jobevents"
+)
+
+// jobProgressMessage - 워커 이벤트를 클라이언트용 job-progress 메시지로 변환
+func jobProgressMessage(event jobevents.Event) Message {
+	data := map[string]interface{}{
+		"jobId":           event.JobID,
+		"event":           event.Event,
+		"totalImages":     event.TotalImages,
+		"completedImages": event.CompletedImages,
+		"timestamp":       event.Timestamp,
+	}
+	if event.Status != "" {
+		data["status"] = event.Status
+	}
+	if event.AttachID != 0 {
+		data["attachId"] = event.AttachID
+	}
+	if event.ProductionID != "" {
+		data["productionId"] = event.ProductionID
+	}
+	if event.URL != "" {
+		data["url"] = event.URL
+	}
+	if event.Detail != nil {
+		data["detail"] = event.Detail
+	}
+	if len(event.AttachIDs) > 0 {
+		data["attachIds"] = event.AttachIDs
+	}
+	if len(event.URLs) > 0 {
+		data["urls"] = event.URLs
+	}
+
+	return Message{
+		Type:        "job-progress",
+		UserId:      event.UserID,
+		OrgId:       event.OrgID,
+		WorkspaceId: event.WorkspaceID,
+		Data:        data,
+	}
+}
+
+// deliverJobProgress - Job의 org/workspace Room과 요청자의 다른 Room 연결에 전달 (이 인스턴스 연결만)
+func (sm *SessionManager) deliverJobProgress(raw []byte) {
+	var event jobevents.Event
+	if err := json.Unmarshal(raw, &event); err != nil || event.JobID == "" {
+		log.Printf("⚠️ [Jobs] Invalid job progress event: %v", err)
+		return
+	}
+
+	payload, err := json.Marshal(jobProgressMessage(event))
+	if err != nil {
+		log.Printf("Error marshaling job progress: %v", err)
+		return
+	}
+
+	roomKey := ""
+	if event.OrgID != "" && event.WorkspaceID != "" {
+		roomKey = event.OrgID + ":" + event.WorkspaceID
+		if session := sm.getSession(roomKey); session != nil {
+			session.deliverLocal("", "", payload)
+		}
+	}
+
+	if event.UserID == "" {
+		return
+	}
+	// Job Room에 있는 연결은 이미 받았으므로 제외
+	for _, session := range sm.listSessions() {
+		if session.id == roomKey {
+			continue
+		}
+		if client := session.getClient(event.UserID); client != nil {
+			client.trySend(payload)
+		}
+	}
+}
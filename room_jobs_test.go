@@ -0,0 +1,73 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"quel-canvas-server/modules/common/jobevents"
+)
+
+// receivedJobProgress - 대기열에서 job-progress 메시지만 꺼냄
+func receivedJobProgress(c *Client) []Message {
+	var messages []Message
+	payloads, _ := c.outbox.take()
+	for _, payload := range payloads {
+		var message Message
+		json.Unmarshal(payload, &message)
+		if message.Type == "job-progress" {
+			messages = append(messages, message)
+		}
+	}
+	return messages
+}
+
+func TestDeliverJobProgressToRoomAndSubmitter(t *testing.T) {
+	sm := &SessionManager{sessions: make(map[string]*Session), metrics: &ServerMetrics{}}
+
+	jobRoom := newSession("org:ws")
+	otherRoom := newSession("org:other")
+	defer jobRoom.stop(true)
+	defer otherRoom.stop(true)
+	sm.sessions[jobRoom.id] = jobRoom
+	sm.sessions[otherRoom.id] = otherRoom
+
+	submitterInRoom := newTestClient("submitter")
+	teammate := newTestClient("teammate")
+	submitterElsewhere := newTestClient("submitter")
+	bystander := newTestClient("bystander")
+	jobRoom.addClient(submitterInRoom)
+	jobRoom.addClient(teammate)
+	otherRoom.addClient(submitterElsewhere)
+	otherRoom.addClient(bystander)
+
+	raw, _ := json.Marshal(jobevents.Event{
+		JobID:           "job-1",
+		Event:           jobevents.EventImageDone,
+		OrgID:           "org",
+		WorkspaceID:     "ws",
+		UserID:          "submitter",
+		TotalImages:     4,
+		CompletedImages: 2,
+		AttachID:        42,
+	})
+	sm.deliverJobProgress(raw)
+
+	var got []Message
+	waitFor(t, "job-progress for teammate", func() bool {
+		got = append(got, receivedJobProgress(teammate)...)
+		return len(got) > 0
+	})
+	if got[0].Data["attachId"] != float64(42) || got[0].Data["completedImages"] != float64(2) {
+		t.Fatalf("unexpected job-progress data: %+v", got[0].Data)
+	}
+
+	waitFor(t, "job-progress for submitter in job room", func() bool {
+		return len(receivedJobProgress(submitterInRoom)) == 1
+	})
+	if n := len(receivedJobProgress(submitterElsewhere)); n != 1 {
+		t.Fatalf("expected submitter's other socket to get 1 job-progress, got %d", n)
+	}
+	if n := len(receivedJobProgress(bystander)); n != 0 {
+		t.Fatalf("bystander in another room received %d job-progress messages", n)
+	}
+}
@@ -0,0 +1,385 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// 아이템 잠금 유지 시간 (main에서 ITEM_LOCK_LEASE_SECONDS로 설정)
+var itemLockLease = 30 * time.Second
+
+// 만료된 잠금 정리 주기
+const itemLockSweepInterval = 5 * time.Second
+
+// itemLock - 드래그/편집 중인 캔버스 아이템 잠금
+type itemLock struct {
+	UserId    string    `json:"userId"`
+	UserName  string    `json:"userName"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// 잠금 대상 아이템을 변경하는 메시지 타입
+var lockedUpdateTypes = map[string]bool{
+	"item_position_update": true,
+	"label_update":         true,
+	"canvas_items_update":  true,
+	"sections_update":      true,
+}
+
+// 인스턴스 간 공유 잠금 키 (collab:room:{room}:lock:{itemId}, 값은 잠근 사용자 JSON, PX = lease)
+const roomLockSuffix = ":lock:"
+
+func roomLockKey(roomKey string, itemId string) string {
+	return roomChannelPrefix + roomKey + roomLockSuffix + itemId
+}
+
+// acquireLockScript - 비어 있으면 SET NX PX로 잠금, 본인 잠금이면 PEXPIRE로 갱신
+// 다른 사용자가 잡고 있으면 {현재 값, 남은 ms} 반환
+var acquireLockScript = redis.NewScript(`
+local current = redis.call('GET', KEYS[1])
+if not current then
+	redis.call('SET', KEYS[1], ARGV[2], 'NX', 'PX', ARGV[3])
+	return {}
+end
+if cjson.decode(current).userId == ARGV[1] then
+	redis.call('PEXPIRE', KEYS[1], ARGV[3])
+	return {}
+end
+return {current, redis.call('PTTL', KEYS[1])}
+`)
+
+// checkLocksScript - 다른 사용자가 잠근 키의 인덱스(1부터) 반환, 본인 잠금은 PEXPIRE로 갱신
+var checkLocksScript = redis.NewScript(`
+local locked = {}
+for i, key in ipairs(KEYS) do
+	local current = redis.call('GET', key)
+	if current then
+		if cjson.decode(current).userId == ARGV[1] then
+			redis.call('PEXPIRE', key, ARGV[2])
+		else
+			table.insert(locked, i)
+		end
+	end
+end
+return locked
+`)
+
+// releaseLockScript - 본인 잠금일 때만 삭제
+var releaseLockScript = redis.NewScript(`
+local current = redis.call('GET', KEYS[1])
+if current and cjson.decode(current).userId == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// messageItemIds - 메시지가 변경하는 아이템 ID 목록
+// (itemId, itemIds, itemUpdates 키, canvasItems의 id, sections의 itemIds/items)
+func messageItemIds(message *Message) []string {
+	var ids []string
+	if message.ItemId != "" {
+		ids = append(ids, message.ItemId)
+	}
+	ids = append(ids, message.ItemIds...)
+	for id := range message.ItemUpdates {
+		ids = append(ids, id)
+	}
+	for _, item := range message.CanvasItems {
+		if id := objectId(item); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	for _, section := range message.Sections {
+		fields, _ := section.(map[string]interface{})
+		if itemIds, ok := fields["itemIds"].([]interface{}); ok {
+			for _, id := range itemIds {
+				if id, ok := id.(string); ok && id != "" {
+					ids = append(ids, id)
+				}
+			}
+		}
+		if items, ok := fields["items"].([]interface{}); ok {
+			for _, item := range items {
+				if id := objectId(item); id != "" {
+					ids = append(ids, id)
+				}
+			}
+		}
+	}
+	return ids
+}
+
+// objectId - JSON 객체의 "id" 문자열 (없으면 빈 문자열)
+func objectId(value interface{}) string {
+	fields, _ := value.(map[string]interface{})
+	id, _ := fields["id"].(string)
+	return id
+}
+
+// lockedPatchNodes - patch-nodes 연산 중 다른 사용자가 잠근 노드 키 목록 ("node:{id}")
+func (s *Session) lockedPatchNodes(userId string, ops []graphOp) []string {
+	var ids []string
+	for _, op := range ops {
+		if op.Target == "" || op.Target == targetNode {
+			ids = append(ids, op.Id)
+		}
+	}
+
+	var keys []string
+	for _, id := range s.checkItemLocks(userId, ids) {
+		keys = append(keys, elementKey(targetNode, id))
+	}
+	return keys
+}
+
+// lockItem - 아이템 잠금 획득 (본인 잠금이면 lease 갱신)
+// 다른 사용자가 잡고 있으면 그 잠금과 false 반환
+// 다중 인스턴스면 Redis 잠금이 기준 (Redis 오류 시 로컬 잠금으로 판단)
+func (s *Session) lockItem(itemId string, userId string, userName string) (itemLock, bool) {
+	holder, held, shared := s.acquireSharedLock(itemId, userId, userName)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := time.Now()
+	if held {
+		if s.locks == nil {
+			s.locks = make(map[string]itemLock)
+		}
+		s.locks[itemId] = holder
+		return holder, false
+	}
+	if current, ok := s.locks[itemId]; !shared && ok && current.UserId != userId && now.Before(current.ExpiresAt) {
+		return current, false
+	}
+
+	if s.locks == nil {
+		s.locks = make(map[string]itemLock)
+	}
+	lock := itemLock{UserId: userId, UserName: userName, ExpiresAt: now.Add(itemLockLease)}
+	s.locks[itemId] = lock
+	return lock, true
+}
+
+// unlockItem - 본인 잠금 해제 (잠금이 없거나 다른 사용자 잠금이면 false)
+func (s *Session) unlockItem(itemId string, userId string) bool {
+	s.mutex.Lock()
+	current, ok := s.locks[itemId]
+	owned := ok && current.UserId == userId
+	if owned {
+		delete(s.locks, itemId)
+	}
+	s.mutex.Unlock()
+
+	if owned {
+		s.releaseSharedLock(itemId, userId)
+	}
+	return owned
+}
+
+// releaseLocksLocked - 사용자의 모든 잠금 해제 (s.mutex 보유 상태에서 호출)
+func (s *Session) releaseLocksLocked(userId string) []string {
+	var released []string
+	for itemId, lock := range s.locks {
+		if lock.UserId == userId {
+			delete(s.locks, itemId)
+			released = append(released, itemId)
+		}
+	}
+	return released
+}
+
+// checkItemLocks - 다른 사용자가 잠근 아이템 목록 반환, 본인 잠금은 lease 갱신
+// 다중 인스턴스면 Redis 잠금이 기준 (Redis 오류 시 로컬 잠금으로 판단)
+func (s *Session) checkItemLocks(userId string, itemIds []string) []string {
+	if len(itemIds) == 0 {
+		return nil
+	}
+
+	sharedLocked, shared := s.checkSharedLocks(userId, itemIds)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := time.Now()
+	var locked []string
+	for _, itemId := range itemIds {
+		lock, ok := s.locks[itemId]
+		if !ok || now.After(lock.ExpiresAt) {
+			continue
+		}
+		if lock.UserId != userId {
+			if !shared {
+				locked = append(locked, itemId)
+			}
+			continue
+		}
+		lock.ExpiresAt = now.Add(itemLockLease)
+		s.locks[itemId] = lock
+	}
+	if shared {
+		locked = append(locked, sharedLocked...)
+	}
+	return locked
+}
+
+// acquireSharedLock - Redis 잠금 획득/갱신, 다른 사용자가 잡고 있으면 그 잠금과 held=true
+// shared=false면 Redis 미사용/오류이므로 로컬 잠금으로 판단
+func (s *Session) acquireSharedLock(itemId string, userId string, userName string) (holder itemLock, held bool, shared bool) {
+	if roomBus == nil {
+		return itemLock{}, false, false
+	}
+
+	value, _ := json.Marshal(itemLock{UserId: userId, UserName: userName})
+
+	ctx, cancel := context.WithTimeout(context.Background(), busTimeout)
+	defer cancel()
+
+	result, err := acquireLockScript.Run(ctx, roomBus.rdb, []string{roomLockKey(s.id, itemId)}, userId, value, itemLockLease.Milliseconds()).Slice()
+	if err != nil {
+		log.Printf("⚠️ [Locks] Failed to acquire shared lock on %s in room %s: %v", itemId, s.id, err)
+		return itemLock{}, false, false
+	}
+	if len(result) < 2 {
+		return itemLock{}, false, true
+	}
+
+	raw, _ := result[0].(string)
+	if err := json.Unmarshal([]byte(raw), &holder); err != nil {
+		log.Printf("⚠️ [Locks] Invalid shared lock on %s in room %s: %v", itemId, s.id, err)
+		return itemLock{}, false, false
+	}
+	ttl, _ := result[1].(int64)
+	holder.ExpiresAt = time.Now().Add(time.Duration(ttl) * time.Millisecond)
+	return holder, true, true
+}
+
+// checkSharedLocks - Redis 기준 다른 사용자가 잠근 아이템 목록 (본인 잠금은 PEXPIRE로 갱신)
+// shared=false면 Redis 미사용/오류이므로 로컬 잠금으로 판단
+func (s *Session) checkSharedLocks(userId string, itemIds []string) (locked []string, shared bool) {
+	if roomBus == nil {
+		return nil, false
+	}
+
+	keys := make([]string, len(itemIds))
+	for i, itemId := range itemIds {
+		keys[i] = roomLockKey(s.id, itemId)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), busTimeout)
+	defer cancel()
+
+	indexes, err := checkLocksScript.Run(ctx, roomBus.rdb, keys, userId, itemLockLease.Milliseconds()).Int64Slice()
+	if err != nil {
+		log.Printf("⚠️ [Locks] Failed to check shared locks in room %s: %v", s.id, err)
+		return nil, false
+	}
+	for _, index := range indexes {
+		locked = append(locked, itemIds[index-1])
+	}
+	return locked, true
+}
+
+// releaseSharedLock - 본인 Redis 잠금 삭제 (해제/퇴장/만료 시)
+func (s *Session) releaseSharedLock(itemId string, userId string) {
+	if roomBus == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), busTimeout)
+	defer cancel()
+
+	if err := releaseLockScript.Run(ctx, roomBus.rdb, []string{roomLockKey(s.id, itemId)}, userId).Err(); err != nil {
+		log.Printf("⚠️ [Locks] Failed to release shared lock on %s in room %s: %v", itemId, s.id, err)
+	}
+}
+
+// activeLocks - 만료되지 않은 잠금 목록 (initial-state용)
+func (s *Session) activeLocks() map[string]itemLock {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	now := time.Now()
+	locks := make(map[string]itemLock, len(s.locks))
+	for itemId, lock := range s.locks {
+		if now.Before(lock.ExpiresAt) {
+			locks[itemId] = lock
+		}
+	}
+	return locks
+}
+
+// expireLocks - lease가 지난 잠금 제거
+func (s *Session) expireLocks() map[string]itemLock {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := time.Now()
+	expired := make(map[string]itemLock)
+	for itemId, lock := range s.locks {
+		if now.After(lock.ExpiresAt) {
+			delete(s.locks, itemId)
+			expired[itemId] = lock
+		}
+	}
+	return expired
+}
+
+// applyRemoteLock - 다른 인스턴스의 잠금/해제를 로컬 세션에 반영
+func (s *Session) applyRemoteLock(message Message) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	switch message.Type {
+	case "item-locked":
+		if s.locks == nil {
+			s.locks = make(map[string]itemLock)
+		}
+		s.locks[message.ItemId] = itemLock{
+			UserId:    message.UserId,
+			UserName:  message.UserName,
+			ExpiresAt: time.Now().Add(itemLockLease),
+		}
+	case "item-unlocked":
+		if current, ok := s.locks[message.ItemId]; ok && current.UserId == message.UserId {
+			delete(s.locks, message.ItemId)
+		}
+	}
+}
+
+// broadcastUnlocked - 잠금 해제 알림 (reason: released | left | expired)
+func (s *Session) broadcastUnlocked(itemId string, userId string, userName string, reason string) {
+	s.broadcastToAll(Message{
+		Type:     "item-unlocked",
+		ItemId:   itemId,
+		UserId:   userId,
+		UserName: userName,
+		Reason:   reason,
+	})
+}
+
+// expireItemLocks - 모든 세션의 만료된 잠금 정리 및 알림
+func (sm *SessionManager) expireItemLocks() {
+	sm.mutex.RLock()
+	sessions := make([]*Session, 0, len(sm.sessions))
+	for _, session := range sm.sessions {
+		sessions = append(sessions, session)
+	}
+	sm.mutex.RUnlock()
+
+	for _, session := range sessions {
+		for itemId, lock := range session.expireLocks() {
+			// 다른 인스턴스 사용자의 잠금은 그 인스턴스가 알림
+			if session.getClient(lock.UserId) == nil {
+				continue
+			}
+			log.Printf("🔓 [Locks] Lock on %s by %s expired in room %s", itemId, lock.UserId, session.id)
+			session.releaseSharedLock(itemId, lock.UserId)
+			session.broadcastUnlocked(itemId, lock.UserId, lock.UserName, "expired")
+		}
+	}
+}
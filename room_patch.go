@@ -0,0 +1,439 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Visual Editor 델타 동기화 연산 종류
+const (
+	opAdd    = "add"
+	opUpdate = "update"
+	opRemove = "remove"
+	opMove   = "move"
+
+	targetNode = "node"
+	targetEdge = "edge"
+)
+
+// graphOp - 노드/엣지 단위 패치 연산
+type graphOp struct {
+	Op       string                 `json:"op"`                 // add | update | remove | move
+	Target   string                 `json:"target,omitempty"`   // node (기본값) | edge
+	Id       string                 `json:"id"`                 // 노드/엣지 ID
+	Value    map[string]interface{} `json:"value,omitempty"`    // add: 전체 객체, update: JSON Merge Patch
+	Position map[string]interface{} `json:"position,omitempty"` // move: {x, y}
+}
+
+// patchConflictError - stale 패치가 다른 사용자의 변경과 겹치는 경우
+type patchConflictError struct {
+	reason    string
+	conflicts []string
+}
+
+func (e *patchConflictError) Error() string {
+	return e.reason
+}
+
+const roomRevSuffix = ":rev"
+
+// 다른 인스턴스 패치를 놓쳤을 때 스냅샷 재로드 시도 횟수 (시도 간격: 스냅샷 debounce + resyncMargin)
+const (
+	resyncAttempts = 5
+	resyncMargin   = 500 * time.Millisecond
+)
+
+// errRevisionBehind - 다른 인스턴스가 먼저 revision을 올림 (이 인스턴스 그래프가 뒤처짐)
+var errRevisionBehind = errors.New("stale revision")
+
+// revisionScript - 현재 revision이 기대값 이하일 때만 다음 revision 발급, 이미 앞서 있으면 현재 값을 음수로 반환
+var revisionScript = redis.NewScript(`
+local current = tonumber(redis.call('GET', KEYS[1]) or '0')
+local expected = tonumber(ARGV[1])
+if current > expected then
+  return -current
+end
+redis.call('SET', KEYS[1], expected + 1, 'EX', ARGV[2])
+return expected + 1
+`)
+
+// fullSyncRevisionScript - 전체 교체(sync-nodes)는 항상 적용되므로 현재 값 이후 revision 발급
+var fullSyncRevisionScript = redis.NewScript(`
+local current = tonumber(redis.call('GET', KEYS[1]) or '0')
+local next = math.max(current, tonumber(ARGV[1])) + 1
+redis.call('SET', KEYS[1], next, 'EX', ARGV[2])
+return next
+`)
+
+func roomRevKey(roomKey string) string {
+	return roomChannelPrefix + roomKey + roomRevSuffix
+}
+
+// allocateRevisionLocked - 다음 revision 발급 (다중 인스턴스면 Redis에서 원자적으로, s.mutex 보유 상태에서 호출)
+// 패치는 Redis revision이 로컬과 같을 때만 발급되므로 두 인스턴스가 같은 revision을 쓰지 않음
+func (s *Session) allocateRevisionLocked(fullSync bool) (int64, error) {
+	if roomBus == nil {
+		return s.revision + 1, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), busTimeout)
+	defer cancel()
+
+	script := revisionScript
+	if fullSync {
+		script = fullSyncRevisionScript
+	}
+	next, err := script.Run(ctx, roomBus.rdb, []string{roomRevKey(s.id)}, s.revision, int(presenceTTL.Seconds())).Int64()
+	if err != nil {
+		return 0, fmt.Errorf("failed to allocate revision: %w", err)
+	}
+	if next < 0 {
+		// 놓친 패치가 있으면 스냅샷으로 따라잡기 (응답에는 클라이언트가 기준으로 쓸 수 있는 로컬 revision)
+		s.requestResyncLocked(-next)
+		return s.revision, errRevisionBehind
+	}
+	return next, nil
+}
+
+func elementKey(target string, id string) string {
+	return target + ":" + id
+}
+
+// currentRevision - 현재 Room 그래프 revision
+func (s *Session) currentRevision() int64 {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.revision
+}
+
+// applyPatch - 패치를 Room 그래프에 적용하고 새 revision과 실제 적용된 연산 반환
+// baseRevision이 현재보다 오래됐으면 겹치는 요소가 없을 때만 현재 그래프 위로 rebase
+func (s *Session) applyPatch(userId string, baseRevision int64, ops []graphOp) (int64, []graphOp, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for i := range ops {
+		if ops[i].Target == "" {
+			ops[i].Target = targetNode
+		}
+	}
+
+	if len(ops) == 0 {
+		return s.revision, nil, &patchConflictError{reason: "empty patch"}
+	}
+	if baseRevision > s.revision {
+		return s.revision, nil, &patchConflictError{reason: "unknown base revision"}
+	}
+
+	if baseRevision < s.revision {
+		// 전체 동기화(sync-nodes) 이전 기준의 패치는 rebase 불가
+		if baseRevision < s.fullSyncRevision {
+			return s.revision, nil, &patchConflictError{reason: "graph replaced by full sync"}
+		}
+
+		var conflicts []string
+		for _, op := range ops {
+			key := elementKey(op.Target, op.Id)
+			if s.elementRevs[key] > baseRevision {
+				conflicts = append(conflicts, key)
+			}
+		}
+		if len(conflicts) > 0 {
+			return s.revision, nil, &patchConflictError{reason: "stale revision", conflicts: conflicts}
+		}
+	}
+
+	revision, applied, changes, err := s.applyOpsLocked(userId, ops)
+	if err != nil {
+		return revision, nil, err
+	}
+
+	// 되돌리기 기록 (새 작업이 생기면 다시 실행 기록은 무효)
+	s.recordHistoryLocked(userId, changes)
+	return revision, applied, nil
+}
+
+// applyOpsLocked - 연산을 그래프에 적용하고 revision 증가 (s.mutex 보유 상태에서 호출)
+// 하나라도 실패하면 아무것도 적용하지 않음. 반환값: revision, 실제 적용된 연산(연쇄 삭제 포함), 요소별 변경 내역
+func (s *Session) applyOpsLocked(userId string, ops []graphOp) (int64, []graphOp, []elementChange, error) {
+	// copy-on-write: initial-state 등에서 잡고 있는 슬라이스를 건드리지 않음
+	nodes := append([]interface{}(nil), s.nodes...)
+	edges := append([]interface{}(nil), s.edges...)
+
+	applied := make([]graphOp, 0, len(ops))
+	for _, op := range ops {
+		var err error
+		var extra []graphOp
+		if op.Target == targetEdge {
+			edges, err = applyGraphOp(edges, op)
+		} else {
+			nodes, err = applyGraphOp(nodes, op)
+			// 노드 삭제 시 연결된 엣지도 함께 삭제
+			if err == nil && op.Op == opRemove {
+				edges, extra = removeConnectedEdges(edges, op.Id)
+			}
+		}
+		if err != nil {
+			return s.revision, nil, nil, &patchConflictError{reason: err.Error(), conflicts: []string{elementKey(op.Target, op.Id)}}
+		}
+		applied = append(applied, op)
+		applied = append(applied, extra...)
+	}
+
+	revision, err := s.allocateRevisionLocked(false)
+	if err == errRevisionBehind {
+		return revision, nil, nil, &patchConflictError{reason: err.Error()}
+	}
+	if err != nil {
+		return s.revision, nil, nil, err
+	}
+
+	changes := diffTouched(s.nodes, nodes, s.edges, edges, applied)
+
+	s.revision = revision
+	if s.elementRevs == nil {
+		s.elementRevs = make(map[string]int64)
+	}
+	for _, op := range applied {
+		s.elementRevs[elementKey(op.Target, op.Id)] = s.revision
+	}
+
+	s.nodes = nodes
+	s.edges = edges
+	s.lastSyncBy = userId
+	s.lastSyncAt = time.Now()
+	s.lastActivity = s.lastSyncAt
+	return s.revision, applied, changes, nil
+}
+
+// applyRemotePatch - 다른 인스턴스에서 이미 적용된 패치 반영 (반환: 적용 여부)
+// revision이 바로 다음 번호가 아니면 적용하지 않고 스냅샷으로 따라잡음 (순서가 뒤바뀐 적용으로 그래프가 갈라지는 것 방지)
+func (s *Session) applyRemotePatch(userId string, revision int64, ops []graphOp) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if revision <= s.revision {
+		return false
+	}
+	if s.resyncTarget > 0 || revision != s.revision+1 {
+		log.Printf("⚠️ [Patch] Room %s missed revisions (local %d, remote %d) - resyncing from snapshot", s.id, s.revision, revision)
+		s.requestResyncLocked(revision)
+		return false
+	}
+
+	nodes := append([]interface{}(nil), s.nodes...)
+	edges := append([]interface{}(nil), s.edges...)
+	for _, op := range ops {
+		var err error
+		if op.Target == targetEdge {
+			edges, err = applyGraphOp(edges, op)
+		} else {
+			nodes, err = applyGraphOp(nodes, op)
+		}
+		if err != nil {
+			log.Printf("⚠️ [Patch] Remote op %s %s skipped in room %s: %v", op.Op, op.Id, s.id, err)
+		}
+	}
+
+	s.revision = revision
+	if s.elementRevs == nil {
+		s.elementRevs = make(map[string]int64)
+	}
+	for _, op := range ops {
+		s.elementRevs[elementKey(op.Target, op.Id)] = s.revision
+	}
+
+	s.nodes = nodes
+	s.edges = edges
+	s.lastSyncBy = userId
+	s.lastSyncAt = time.Now()
+	s.lastActivity = s.lastSyncAt
+	return true
+}
+
+// requestResyncLocked - 놓친 revision 기록 후 스냅샷 재로드 시작 (이미 진행 중이면 목표만 올림, s.mutex 보유 상태에서 호출)
+// 스냅샷 저장소가 없으면 따라잡을 방법이 없으므로 목표를 기록하지 않고 로컬 클라이언트에 바로 resync-required
+func (s *Session) requestResyncLocked(target int64) {
+	if snapshotStore == nil {
+		go s.deliverLocal("", "", resyncRequiredPayload)
+		return
+	}
+	if target <= s.resyncTarget {
+		return
+	}
+	started := s.resyncTarget > 0
+	s.resyncTarget = target
+	if !started {
+		go s.resyncFromSnapshot()
+	}
+}
+
+// resyncFromSnapshot - 패치를 만든 인스턴스가 저장한 스냅샷으로 Room 그래프 교체 후 로컬 클라이언트에 resync-required 전송
+func (s *Session) resyncFromSnapshot() {
+	for attempt := 1; attempt <= resyncAttempts; attempt++ {
+		time.Sleep(snapshotStore.debounce + resyncMargin)
+
+		snapshot, err := snapshotStore.load(s.id)
+		if err != nil {
+			log.Printf("❌ [Patch] Failed to load snapshot for room %s resync: %v", s.id, err)
+			continue
+		}
+
+		s.mutex.Lock()
+		// 그 사이 전체 동기화(nodes-updated)로 따라잡은 경우
+		if s.revision >= s.resyncTarget {
+			s.resyncTarget = 0
+			s.mutex.Unlock()
+			return
+		}
+		if snapshot == nil || snapshot.Revision < s.resyncTarget {
+			s.mutex.Unlock()
+			continue
+		}
+
+		s.nodes = snapshot.Nodes
+		s.edges = snapshot.Edges
+		s.lastSyncBy = snapshot.LastSyncBy
+		s.lastSyncAt = snapshot.LastSyncAt
+		s.revision = snapshot.Revision
+		s.fullSyncRevision = snapshot.Revision
+		s.elementRevs = nil
+		s.resyncTarget = 0
+		s.mutex.Unlock()
+
+		log.Printf("🔄 [Patch] Room %s resynced from snapshot at rev %d", s.id, snapshot.Revision)
+		s.deliverLocal("", "", resyncRequiredPayload)
+		return
+	}
+
+	s.mutex.Lock()
+	target := s.resyncTarget
+	s.resyncTarget = 0
+	s.mutex.Unlock()
+	log.Printf("❌ [Patch] Room %s could not catch up to rev %d from snapshot", s.id, target)
+	s.deliverLocal("", "", resyncRequiredPayload)
+}
+
+// applyGraphOp - 단일 연산 적용 (elements는 호출자가 복사한 슬라이스)
+func applyGraphOp(elements []interface{}, op graphOp) ([]interface{}, error) {
+	if op.Id == "" {
+		return elements, fmt.Errorf("missing id")
+	}
+	index := findElement(elements, op.Id)
+
+	switch op.Op {
+	case opAdd:
+		if index >= 0 {
+			return elements, fmt.Errorf("%s already exists", op.Id)
+		}
+		if op.Value == nil {
+			return elements, fmt.Errorf("missing value for add")
+		}
+		element := deepCopyJSON(op.Value).(map[string]interface{})
+		element["id"] = op.Id
+		return append(elements, element), nil
+
+	case opUpdate:
+		if index < 0 {
+			return elements, fmt.Errorf("%s not found", op.Id)
+		}
+		current, _ := elements[index].(map[string]interface{})
+		merged := mergePatch(current, op.Value)
+		merged["id"] = op.Id
+		elements[index] = merged
+		return elements, nil
+
+	case opMove:
+		if index < 0 {
+			return elements, fmt.Errorf("%s not found", op.Id)
+		}
+		if op.Position == nil {
+			return elements, fmt.Errorf("missing position for move")
+		}
+		current, _ := elements[index].(map[string]interface{})
+		moved := mergePatch(current, nil)
+		moved["position"] = deepCopyJSON(op.Position)
+		elements[index] = moved
+		return elements, nil
+
+	case opRemove:
+		if index < 0 {
+			return elements, fmt.Errorf("%s not found", op.Id)
+		}
+		return append(elements[:index], elements[index+1:]...), nil
+	}
+
+	return elements, fmt.Errorf("unknown op: %s", op.Op)
+}
+
+// removeConnectedEdges - 노드에 연결된 엣지 삭제 후 삭제 연산 목록 반환
+func removeConnectedEdges(edges []interface{}, nodeId string) ([]interface{}, []graphOp) {
+	kept := edges[:0]
+	var removed []graphOp
+	for _, element := range edges {
+		edge, _ := element.(map[string]interface{})
+		if edge != nil && (edge["source"] == nodeId || edge["target"] == nodeId) {
+			if id, ok := edge["id"].(string); ok {
+				removed = append(removed, graphOp{Op: opRemove, Target: targetEdge, Id: id})
+			}
+			continue
+		}
+		kept = append(kept, element)
+	}
+	return kept, removed
+}
+
+func findElement(elements []interface{}, id string) int {
+	for i, element := range elements {
+		if m, ok := element.(map[string]interface{}); ok && m["id"] == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// mergePatch - JSON Merge Patch (RFC 7386) 적용 결과를 새 맵으로 반환 (원본 불변)
+func mergePatch(target map[string]interface{}, patch map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(target)+len(patch))
+	for k, v := range target {
+		result[k] = v
+	}
+	for k, v := range patch {
+		if v == nil {
+			delete(result, k)
+			continue
+		}
+		if patchMap, ok := v.(map[string]interface{}); ok {
+			currentMap, _ := result[k].(map[string]interface{})
+			result[k] = mergePatch(currentMap, patchMap)
+			continue
+		}
+		result[k] = deepCopyJSON(v)
+	}
+	return result
+}
+
+// deepCopyJSON - JSON 디코딩 값(map/slice/scalar) 깊은 복사
+func deepCopyJSON(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			copied[k] = deepCopyJSON(item)
+		}
+		return copied
+	case []interface{}:
+		copied := make([]interface{}, len(v))
+		for i, item := range v {
+			copied[i] = deepCopyJSON(item)
+		}
+		return copied
+	default:
+		return v
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+)
+
+// 커서/선택 묶음 전송 주기 (main에서 PRESENCE_TICK_MS로 설정)
+var presenceTickInterval = 50 * time.Millisecond
+
+// presenceBuffer - 다음 tick에 보낼 사용자별 최신 커서/선택 (type:userId → 메시지)
+type presenceBuffer struct {
+	mutex   sync.Mutex
+	order   []string
+	updates map[string]presenceUpdate
+}
+
+type presenceUpdate struct {
+	message Message
+	remote  bool // 다른 인스턴스에서 받은 업데이트 (다시 발행하지 않음)
+}
+
+// queuePresence - 커서/선택 업데이트 저장 (같은 사용자의 이전 값은 대체)
+func (s *Session) queuePresence(message Message, remote bool) {
+	key := coalesceKey(&message)
+
+	s.presence.mutex.Lock()
+	defer s.presence.mutex.Unlock()
+
+	if s.presence.updates == nil {
+		s.presence.updates = make(map[string]presenceUpdate)
+	}
+	if _, exists := s.presence.updates[key]; !exists {
+		s.presence.order = append(s.presence.order, key)
+	}
+	s.presence.updates[key] = presenceUpdate{message: message, remote: remote}
+}
+
+// takePresence - 대기 중인 업데이트를 순서대로 꺼냄
+func (s *Session) takePresence() []presenceUpdate {
+	s.presence.mutex.Lock()
+	defer s.presence.mutex.Unlock()
+
+	if len(s.presence.order) == 0 {
+		return nil
+	}
+	updates := make([]presenceUpdate, 0, len(s.presence.order))
+	for _, key := range s.presence.order {
+		updates = append(updates, s.presence.updates[key])
+	}
+	s.presence.order = nil
+	s.presence.updates = nil
+	return updates
+}
+
+// flushPresence - tick마다 모인 커서/선택을 수신자별 presence-batch 한 프레임으로 전달 (hub 고루틴)
+func (s *Session) flushPresence() {
+	updates := s.takePresence()
+	if len(updates) == 0 {
+		return
+	}
+
+	// 이 인스턴스 사용자의 업데이트만 다른 인스턴스로 묶어서 발행
+	var local []Message
+	for _, update := range updates {
+		if !update.remote {
+			local = append(local, update.message)
+		}
+	}
+	if len(local) > 0 && roomBus != nil {
+		payload, err := json.Marshal(Message{Type: "presence-batch", Updates: local})
+		if err != nil {
+			log.Printf("Error marshaling presence batch: %v", err)
+		} else {
+			go roomBus.publish(s.id, "", payload)
+		}
+	}
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	for userId, client := range s.clients {
+		// 본인 커서/선택은 제외
+		batch := make([]Message, 0, len(updates))
+		for _, update := range updates {
+			if update.message.UserId != userId {
+				batch = append(batch, update.message)
+			}
+		}
+		if len(batch) > 0 {
+			client.outbox.pushPresence(batch)
+		}
+	}
+}
+
+// applyRemotePresence - 다른 인스턴스의 presence-batch를 다음 tick에 합쳐서 전달
+func (s *Session) applyRemotePresence(message Message) {
+	for _, update := range message.Updates {
+		s.queuePresence(update, true)
+	}
+}
@@ -0,0 +1,509 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	"github.com/redis/go-redis/v9"
+)
+
+// Room 녹화 저장 위치 (Redis Stream)
+const (
+	recordingKeyPrefix   = "collab:recording:" // + recordingId → 메시지 Stream
+	recordingMetaSuffix  = ":meta"             // 녹화 정보 (JSON)
+	roomRecordingSuffix  = ":recording"        // Room의 진행 중 녹화 ID
+	roomRecordingsSuffix = ":recordings"       // Room 녹화 목록 (시작 시각 ZSET)
+	recordingTimeout     = 2 * time.Second
+)
+
+// 재생 설정
+const (
+	playbackMinSpeed = 0.1
+	playbackMaxSpeed = 32.0
+	playbackMaxGap   = 10 * time.Second // 긴 공백은 이 시간으로 줄여서 재생 (배속 적용 전)
+	playbackBatch    = 200
+)
+
+// 녹화 보관 기간/최대 메시지 수 (main에서 ROOM_RECORDING_TTL_HOURS, ROOM_RECORDING_MAX_EVENTS로 설정)
+var (
+	recordingTTL       = 168 * time.Hour
+	recordingMaxEvents = int64(100000)
+)
+
+// 녹화 정보
+type recordingMeta struct {
+	RecordingId string     `json:"recordingId"`
+	RoomKey     string     `json:"roomKey"`
+	StartedBy   string     `json:"startedBy"`
+	StartedAt   time.Time  `json:"startedAt"`
+	StoppedBy   string     `json:"stoppedBy,omitempty"`
+	StoppedAt   *time.Time `json:"stoppedAt,omitempty"`
+	Events      int64      `json:"events"`
+}
+
+func recordingStreamKey(recordingId string) string {
+	return recordingKeyPrefix + recordingId
+}
+
+func recordingMetaKey(recordingId string) string {
+	return recordingKeyPrefix + recordingId + recordingMetaSuffix
+}
+
+func roomRecordingKey(roomKey string) string {
+	return roomChannelPrefix + roomKey + roomRecordingSuffix
+}
+
+func roomRecordingsKey(roomKey string) string {
+	return roomChannelPrefix + roomKey + roomRecordingsSuffix
+}
+
+// currentRecording - 진행 중인 녹화 ID (없으면 빈 문자열)
+func (s *Session) currentRecording() string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.recordingId
+}
+
+func (s *Session) setRecording(recordingId string) {
+	s.mutex.Lock()
+	s.recordingId = recordingId
+	s.mutex.Unlock()
+}
+
+// loadRecording - 다른 인스턴스에서 시작한 녹화가 있으면 이어서 기록 (세션 생성 시)
+func (s *Session) loadRecording() {
+	if roomBus == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), recordingTimeout)
+	defer cancel()
+
+	recordingId, err := roomBus.rdb.Get(ctx, roomRecordingKey(s.id)).Result()
+	if err != nil {
+		if err != redis.Nil {
+			log.Printf("❌ [Recording] Failed to load recording state for %s: %v", s.id, err)
+		}
+		return
+	}
+	s.setRecording(recordingId)
+	log.Printf("⏺️ [Recording] Room %s joins recording %s", s.id, recordingId)
+}
+
+// 녹화 대상 메시지 타입 (nodes-patched는 nodes-updated의 부분 변경 형태라 함께 기록)
+var recordedMessageTypes = map[string]bool{
+	"nodes-updated":       true,
+	"nodes-patched":       true,
+	"canvas_items_update": true,
+	"sections_update":     true,
+	"label_update":        true,
+}
+
+// 녹화 시작 시점의 Room 상태 (Stream 첫 항목, 재생 시 initial-state로 전송)
+const recordingBaselineType = "baseline"
+
+// recordScript - Room의 진행 중 녹화가 이 녹화일 때만 Stream에 추가 (종료/만료됐으면 0)
+var recordScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) ~= ARGV[1] then
+	return 0
+end
+redis.call('XADD', KEYS[2], 'MAXLEN', '~', ARGV[2], '*', 'type', ARGV[3], 'payload', ARGV[4])
+return 1
+`)
+
+// clearRecording - 로컬 녹화 ID가 아직 recordingId일 때만 해제
+func (s *Session) clearRecording(recordingId string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.recordingId == recordingId {
+		s.recordingId = ""
+	}
+}
+
+// recordMessage - 녹화 중이면 대상 메시지를 Stream에 추가 (broadcast에서 호출)
+// Room의 녹화 키가 사라졌으면 (종료 알림 누락, 보관 기간 만료) 로컬 녹화 상태도 해제
+func (s *Session) recordMessage(messageType string, payload []byte) {
+	if !recordedMessageTypes[messageType] {
+		return
+	}
+	recordingId := s.currentRecording()
+	if recordingId == "" || roomBus == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), recordingTimeout)
+	defer cancel()
+
+	recorded, err := recordScript.Run(ctx, roomBus.rdb,
+		[]string{roomRecordingKey(s.id), recordingStreamKey(recordingId)},
+		recordingId, recordingMaxEvents, messageType, payload).Int()
+	if err != nil {
+		log.Printf("❌ [Recording] Failed to record %s in %s: %v", messageType, recordingId, err)
+		return
+	}
+	if recorded == 0 {
+		s.clearRecording(recordingId)
+		log.Printf("⏹️ [Recording] Recording %s is no longer active in room %s - stopped recording locally", recordingId, s.id)
+	}
+}
+
+// recordingBaseline - 녹화 시작 시점의 Room 상태 (initial-state 형태)
+func (s *Session) recordingBaseline() ([]byte, error) {
+	s.mutex.RLock()
+	baseline := Message{
+		Type: "initial-state",
+		Data: map[string]interface{}{
+			"nodes":      s.nodes,
+			"edges":      s.edges,
+			"lastSyncBy": s.lastSyncBy,
+			"lastSyncAt": s.lastSyncAt,
+			"revision":   s.revision,
+		},
+		Seq: s.seq,
+	}
+	encoded, err := json.Marshal(baseline)
+	s.mutex.RUnlock()
+	return encoded, err
+}
+
+// startRecording - 녹화 시작 (Room당 하나, 다른 인스턴스와 SETNX로 경쟁)
+func (s *Session) startRecording(c *Client) (recordingMeta, error) {
+	if roomBus == nil {
+		return recordingMeta{}, fmt.Errorf("recording disabled")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), recordingTimeout)
+	defer cancel()
+
+	meta := recordingMeta{
+		RecordingId: uuid.NewString(),
+		RoomKey:     s.id,
+		StartedBy:   c.userId,
+		StartedAt:   time.Now().UTC(),
+	}
+	ok, err := roomBus.rdb.SetNX(ctx, roomRecordingKey(s.id), meta.RecordingId, recordingTTL).Result()
+	if err != nil {
+		return recordingMeta{}, fmt.Errorf("failed to start recording: %w", err)
+	}
+	if !ok {
+		return recordingMeta{}, fmt.Errorf("already recording")
+	}
+
+	encoded, err := json.Marshal(meta)
+	if err != nil {
+		roomBus.rdb.Del(ctx, roomRecordingKey(s.id))
+		return recordingMeta{}, err
+	}
+	baseline, err := s.recordingBaseline()
+	if err != nil {
+		roomBus.rdb.Del(ctx, roomRecordingKey(s.id))
+		return recordingMeta{}, err
+	}
+
+	// 기준 상태를 Stream 첫 항목으로 기록하고, 종료 없이 방치돼도 보관 기간 후 삭제되도록 만료 설정
+	pipe := roomBus.rdb.TxPipeline()
+	pipe.Set(ctx, recordingMetaKey(meta.RecordingId), encoded, recordingTTL)
+	pipe.XAdd(ctx, &redis.XAddArgs{
+		Stream: recordingStreamKey(meta.RecordingId),
+		Values: map[string]interface{}{"type": recordingBaselineType, "payload": baseline},
+	})
+	pipe.Expire(ctx, recordingStreamKey(meta.RecordingId), recordingTTL)
+	pipe.ZAdd(ctx, roomRecordingsKey(s.id), redis.Z{Score: float64(meta.StartedAt.UnixMilli()), Member: meta.RecordingId})
+	pipe.Expire(ctx, roomRecordingsKey(s.id), recordingTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		roomBus.rdb.Del(ctx, roomRecordingKey(s.id))
+		return recordingMeta{}, fmt.Errorf("failed to save recording: %w", err)
+	}
+
+	s.setRecording(meta.RecordingId)
+	log.Printf("⏺️ [Recording] %s started recording %s in room %s", c.userId, meta.RecordingId, s.id)
+	return meta, nil
+}
+
+// stopRecording - 녹화 종료 (Stream은 보관 기간 동안 유지)
+func (s *Session) stopRecording(c *Client) (recordingMeta, error) {
+	if roomBus == nil {
+		return recordingMeta{}, fmt.Errorf("recording disabled")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), recordingTimeout)
+	defer cancel()
+
+	recordingId, err := roomBus.rdb.GetDel(ctx, roomRecordingKey(s.id)).Result()
+	if err == redis.Nil {
+		return recordingMeta{}, fmt.Errorf("not recording")
+	}
+	if err != nil {
+		return recordingMeta{}, fmt.Errorf("failed to stop recording: %w", err)
+	}
+	s.setRecording("")
+
+	meta, err := loadRecordingMeta(ctx, recordingId)
+	if err != nil || meta == nil {
+		return recordingMeta{RecordingId: recordingId, RoomKey: s.id}, err
+	}
+	stoppedAt := time.Now().UTC()
+	meta.StoppedBy = c.userId
+	meta.StoppedAt = &stoppedAt
+	encoded, err := json.Marshal(meta)
+	if err != nil {
+		return *meta, err
+	}
+
+	pipe := roomBus.rdb.TxPipeline()
+	pipe.Set(ctx, recordingMetaKey(recordingId), encoded, recordingTTL)
+	pipe.Expire(ctx, recordingStreamKey(recordingId), recordingTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return *meta, fmt.Errorf("failed to save recording: %w", err)
+	}
+
+	log.Printf("⏹️ [Recording] %s stopped recording %s in room %s", c.userId, recordingId, s.id)
+	return *meta, nil
+}
+
+// handleRecording - recording-start / recording-stop 처리 후 recording-state 브로드캐스트 (host 전용)
+func (s *Session) handleRecording(c *Client, message Message) {
+	var (
+		meta recordingMeta
+		err  error
+	)
+	if message.Type == "recording-start" {
+		meta, err = s.startRecording(c)
+	} else {
+		meta, err = s.stopRecording(c)
+	}
+	if err != nil {
+		log.Printf("❌ [Recording] %s from %s in room %s failed: %v", message.Type, c.userId, s.id, err)
+		c.sendMessage(Message{
+			Type:   "error",
+			Reason: err.Error(),
+			Data:   map[string]interface{}{"messageType": message.Type},
+		})
+		return
+	}
+
+	s.broadcastToAll(Message{
+		Type:        "recording-state",
+		UserId:      c.userId,
+		UserName:    c.userName,
+		OrgId:       c.orgId,
+		WorkspaceId: c.workspaceId,
+		Data: map[string]interface{}{
+			"recording":   message.Type == "recording-start",
+			"recordingId": meta.RecordingId,
+			"startedAt":   meta.StartedAt,
+		},
+	})
+}
+
+// applyRemoteRecording - 다른 인스턴스의 녹화 시작/종료 반영
+func (s *Session) applyRemoteRecording(message Message) {
+	recordingId, _ := message.Data["recordingId"].(string)
+	if recording, _ := message.Data["recording"].(bool); recording {
+		s.setRecording(recordingId)
+	} else {
+		s.clearRecording(recordingId)
+	}
+}
+
+func loadRecordingMeta(ctx context.Context, recordingId string) (*recordingMeta, error) {
+	data, err := roomBus.rdb.Get(ctx, recordingMetaKey(recordingId)).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read recording: %w", err)
+	}
+	var meta recordingMeta
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return nil, fmt.Errorf("failed to parse recording: %w", err)
+	}
+	meta.Events, _ = roomBus.rdb.XLen(ctx, recordingStreamKey(recordingId)).Result()
+	return &meta, nil
+}
+
+// registerRecordingRoutes - 녹화 목록/재생 라우트 등록
+func registerRecordingRoutes(r *mux.Router) {
+	r.HandleFunc("/api/workspaces/{orgId}/{workspaceId}/recordings", listRecordings).Methods("GET")
+	r.HandleFunc("/recordings/{recordingId}/play", playRecording)
+}
+
+// GET /api/workspaces/{orgId}/{workspaceId}/recordings - 최근 녹화 목록 (최신순)
+func listRecordings(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	vars := mux.Vars(r)
+	roomKey := vars["orgId"] + ":" + vars["workspaceId"]
+
+	if roomBus == nil {
+		http.Error(w, `{"error": "recording disabled"}`, http.StatusServiceUnavailable)
+		return
+	}
+	if !authorizeRoomRequest(w, r, roomKey) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), recordingTimeout)
+	defer cancel()
+
+	ids, err := roomBus.rdb.ZRevRange(ctx, roomRecordingsKey(roomKey), 0, -1).Result()
+	if err != nil {
+		http.Error(w, `{"error": "failed to list recordings"}`, http.StatusInternalServerError)
+		return
+	}
+	recordings := make([]recordingMeta, 0, len(ids))
+	for _, id := range ids {
+		// 보관 기간이 지난 녹화는 건너뜀
+		if meta, err := loadRecordingMeta(ctx, id); err == nil && meta != nil {
+			recordings = append(recordings, *meta)
+		}
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"roomKey":    roomKey,
+		"recordings": recordings,
+	})
+}
+
+// WS /recordings/{recordingId}/play?speed=2 - 녹화를 원래 간격/배속으로 읽기 전용 클라이언트에 재생
+func playRecording(w http.ResponseWriter, r *http.Request) {
+	recordingId := mux.Vars(r)["recordingId"]
+
+	if roomBus == nil {
+		http.Error(w, `{"error": "recording disabled"}`, http.StatusServiceUnavailable)
+		return
+	}
+
+	speed := 1.0
+	if speedStr := r.URL.Query().Get("speed"); speedStr != "" {
+		parsed, err := strconv.ParseFloat(speedStr, 64)
+		if err != nil || parsed < playbackMinSpeed || parsed > playbackMaxSpeed {
+			http.Error(w, fmt.Sprintf(`{"error": "speed must be between %g and %g"}`, playbackMinSpeed, playbackMaxSpeed), http.StatusBadRequest)
+			return
+		}
+		speed = parsed
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), recordingTimeout)
+	meta, err := loadRecordingMeta(ctx, recordingId)
+	cancel()
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error": %q}`, err.Error()), http.StatusInternalServerError)
+		return
+	}
+	if meta == nil {
+		http.Error(w, `{"error": "recording not found"}`, http.StatusNotFound)
+		return
+	}
+	if !authorizeRoomRequest(w, r, meta.RoomKey) {
+		return
+	}
+
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Printf("WebSocket upgrade failed: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	// 읽기 전용: 받은 메시지는 버리고 연결 종료만 감지
+	playCtx, stop := context.WithCancel(context.Background())
+	defer stop()
+	go func() {
+		defer stop()
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	log.Printf("▶️ [Recording] Playing %s (%d events) at %gx", recordingId, meta.Events, speed)
+	if err := writePlaybackMessage(conn, Message{
+		Type: "playback-start",
+		Data: map[string]interface{}{"recording": meta, "speed": speed},
+	}); err != nil {
+		return
+	}
+
+	sent, err := streamRecording(playCtx, conn, recordingId, speed)
+	if err != nil {
+		log.Printf("⚠️ [Recording] Playback of %s stopped after %d events: %v", recordingId, sent, err)
+		return
+	}
+
+	writePlaybackMessage(conn, Message{
+		Type: "playback-complete",
+		Data: map[string]interface{}{"recordingId": recordingId, "events": sent},
+	})
+	conn.WriteControl(websocket.CloseMessage,
+		websocket.FormatCloseMessage(websocket.CloseNormalClosure, "playback complete"),
+		time.Now().Add(writeWait))
+	log.Printf("⏹️ [Recording] Finished playing %s (%d events)", recordingId, sent)
+}
+
+// streamRecording - Stream을 순서대로 읽어 기록된 간격(배속 적용)에 맞춰 전송
+func streamRecording(ctx context.Context, conn *websocket.Conn, recordingId string, speed float64) (int, error) {
+	sent := 0
+	start := "-"
+	var previous time.Time
+
+	for {
+		entries, err := roomBus.rdb.XRangeN(ctx, recordingStreamKey(recordingId), start, "+", playbackBatch).Result()
+		if err != nil {
+			return sent, err
+		}
+
+		for _, entry := range entries {
+			recordedAt := streamEntryTime(entry.ID)
+			if !previous.IsZero() {
+				gap := recordedAt.Sub(previous)
+				if gap > playbackMaxGap {
+					gap = playbackMaxGap
+				}
+				if gap > 0 {
+					timer := time.NewTimer(time.Duration(float64(gap) / speed))
+					select {
+					case <-ctx.Done():
+						timer.Stop()
+						return sent, ctx.Err()
+					case <-timer.C:
+					}
+				}
+			}
+			previous = recordedAt
+
+			payload, _ := entry.Values["payload"].(string)
+			conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := conn.WriteMessage(websocket.TextMessage, []byte(payload)); err != nil {
+				return sent, err
+			}
+			sent++
+		}
+
+		if len(entries) < playbackBatch {
+			return sent, nil
+		}
+		// 다음 배치는 마지막 항목 다음부터 (exclusive range)
+		start = "(" + entries[len(entries)-1].ID
+	}
+}
+
+func writePlaybackMessage(conn *websocket.Conn, message Message) error {
+	conn.SetWriteDeadline(time.Now().Add(writeWait))
+	return conn.WriteJSON(message)
+}
+
+// streamEntryTime - Stream ID("<ms>-<seq>")의 기록 시각
+func streamEntryTime(id string) time.Time {
+	msStr, _, _ := strings.Cut(id, "-")
+	ms, err := strconv.ParseInt(msStr, 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.UnixMilli(ms)
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// 재접속 재전송 버퍼 크기 (main에서 ROOM_REPLAY_BUFFER로 설정)
+var replayBufferSize = 1000
+
+const roomSeqSuffix = ":seq"
+
+// 순번을 붙이지 않는 메시지 타입 (다음 메시지가 이전 값을 대체하므로 재전송 불필요)
+var ephemeralMessageTypes = map[string]bool{
+	"cursor-update":    true,
+	"cursor_move":      true,
+	"selection-update": true,
+	"user_selection":   true,
+}
+
+// 재전송 버퍼 항목
+type replayEntry struct {
+	seq     int64
+	exclude string // 이 사용자에게는 원래 전달되지 않은 메시지 (본인이 보낸 메시지)
+	payload []byte
+}
+
+func roomSeqKey(roomKey string) string {
+	return roomChannelPrefix + roomKey + roomSeqSuffix
+}
+
+// Room 순번 발급 제한 시간 (넘으면 순번 없이 전송해 브로드캐스트가 Redis 지연에 묶이지 않도록)
+const seqTimeout = 500 * time.Millisecond
+
+// seqScript - Room 순번 증가와 만료 갱신을 한 번의 요청으로
+var seqScript = redis.NewScript(`
+local seq = redis.call('INCR', KEYS[1])
+redis.call('EXPIRE', KEYS[1], ARGV[1])
+return seq
+`)
+
+// nextSeq - Room 순번 발급 (다중 인스턴스면 Redis INCR로 전역 순번)
+// Redis 실패 시 로컬 순번을 만들지 않고 ok=false (호출자는 순번 없이 전송하고 markSeqGap 호출)
+func (s *Session) nextSeq() (int64, bool) {
+	if roomBus != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), seqTimeout)
+		defer cancel()
+
+		seq, err := seqScript.Run(ctx, roomBus.rdb, []string{roomSeqKey(s.id)}, int(presenceTTL.Seconds())).Int64()
+		if err != nil {
+			log.Printf("⚠️ [Replay] Redis INCR failed for room %s, sending without sequence: %v", s.id, err)
+			return 0, false
+		}
+		return seq, true
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.seq++
+	return s.seq, true
+}
+
+// markSeqGap - 순번 없이 전달된 메시지가 있음을 기록 (그 이전 순번으로 resume하면 재전송 대신 전체 상태)
+func (s *Session) markSeqGap() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.resumeFloor = s.seq + 1
+}
+
+// recordReplay - 재전송 버퍼에 메시지 저장 (순번 순서 유지, 오래된 항목부터 제거)
+func (s *Session) recordReplay(seq int64, excludeUserId string, payload []byte) {
+	if replayBufferSize <= 0 {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if seq > s.seq {
+		s.seq = seq
+	}
+
+	entry := replayEntry{seq: seq, exclude: excludeUserId, payload: payload}
+	n := len(s.replay)
+	if n == 0 || s.replay[n-1].seq < seq {
+		s.replay = append(s.replay, entry)
+	} else {
+		// 다른 인스턴스 메시지가 늦게 도착한 경우
+		i := sort.Search(n, func(i int) bool { return s.replay[i].seq >= seq })
+		if i < n && s.replay[i].seq == seq {
+			return
+		}
+		s.replay = append(s.replay, replayEntry{})
+		copy(s.replay[i+1:], s.replay[i:])
+		s.replay[i] = entry
+	}
+
+	if len(s.replay) > replayBufferSize {
+		s.replay = append([]replayEntry(nil), s.replay[len(s.replay)-replayBufferSize:]...)
+	}
+}
+
+// replaySince - lastSeq 이후 놓친 메시지 반환
+// 버퍼가 lastSeq+1부터 s.seq까지 빠짐없이 이어지지 않으면 ok=false (전체 상태 재전송 필요)
+func (s *Session) replaySince(lastSeq int64, userId string) ([][]byte, int64, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if lastSeq == s.seq {
+		return nil, s.seq, true
+	}
+	// 서버 재시작 등으로 클라이언트가 더 앞선 순번을 가진 경우, 그 사이 순번 없이 보낸 메시지가 있는 경우
+	if lastSeq > s.seq || lastSeq < s.resumeFloor || len(s.replay) == 0 || s.replay[0].seq > lastSeq+1 {
+		return nil, s.seq, false
+	}
+
+	// Pub/Sub 유실이나 인스턴스 간 순서 뒤바뀜으로 버퍼 중간에 빠진 순번이 있으면 재전송으로 복구 불가
+	var missed [][]byte
+	expected := lastSeq + 1
+	for _, entry := range s.replay {
+		if entry.seq <= lastSeq {
+			continue
+		}
+		if entry.seq != expected {
+			return nil, s.seq, false
+		}
+		expected++
+		if entry.exclude != userId {
+			missed = append(missed, entry.payload)
+		}
+	}
+	if expected != s.seq+1 {
+		return nil, s.seq, false
+	}
+	return missed, s.seq, true
+}
+
+// resume - 재접속 클라이언트에게 놓친 메시지 재전송 (불가능하면 initial-state)
+func (s *Session) resume(c *Client, lastSeq int64) {
+	missed, currentSeq, ok := s.replaySince(lastSeq, c.userId)
+	if !ok {
+		log.Printf("🔁 [Replay] Gap too large for %s in room %s (last %d, current %d) - sending full state",
+			c.userId, s.id, lastSeq, currentSeq)
+		s.sendInitialState(c, true)
+		return
+	}
+
+	for _, payload := range missed {
+		if !c.trySend(payload) {
+			log.Printf("⚠️ [Replay] Send buffer full while replaying to %s - sending full state", c.userId)
+			s.sendInitialState(c, true)
+			return
+		}
+	}
+
+	c.sendMessage(Message{Type: "resume-complete", Seq: currentSeq})
+	log.Printf("🔁 [Replay] Replayed %d messages to %s in room %s (%d → %d)",
+		len(missed), c.userId, s.id, lastSeq, currentSeq)
+}
@@ -0,0 +1,53 @@
+package main
+
+import "testing"
+
+func TestReplaySinceRequiresContiguousBuffer(t *testing.T) {
+	cases := []struct {
+		name     string
+		seqs     []int64 // recordReplay 순서 (다른 인스턴스 메시지가 늦게 도착하는 경우 포함)
+		lastSeq  int64
+		wantOK   bool
+		wantSent int
+	}{
+		{name: "contiguous", seqs: []int64{1, 2, 3, 4}, lastSeq: 2, wantOK: true, wantSent: 2},
+		{name: "up to date", seqs: []int64{1, 2, 3}, lastSeq: 3, wantOK: true, wantSent: 0},
+		{name: "out of order arrival", seqs: []int64{1, 3, 2, 4}, lastSeq: 1, wantOK: true, wantSent: 3},
+		{name: "hole inside buffer", seqs: []int64{1, 2, 4, 5}, lastSeq: 1, wantOK: false},
+		{name: "hole before lastSeq ignored", seqs: []int64{1, 3, 4}, lastSeq: 3, wantOK: true, wantSent: 1},
+		{name: "buffer starts after lastSeq", seqs: []int64{5, 6}, lastSeq: 2, wantOK: false},
+		{name: "client ahead of server", seqs: []int64{1, 2}, lastSeq: 7, wantOK: false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := &Session{id: "org:workspace"}
+			for _, seq := range tc.seqs {
+				s.recordReplay(seq, "", []byte(`{}`))
+			}
+
+			missed, _, ok := s.replaySince(tc.lastSeq, "user")
+			if ok != tc.wantOK {
+				t.Fatalf("ok = %v, want %v", ok, tc.wantOK)
+			}
+			if ok && len(missed) != tc.wantSent {
+				t.Fatalf("replayed %d messages, want %d", len(missed), tc.wantSent)
+			}
+		})
+	}
+}
+
+func TestReplaySinceSkipsOwnMessages(t *testing.T) {
+	s := &Session{id: "org:workspace"}
+	s.recordReplay(1, "", []byte(`{"n":1}`))
+	s.recordReplay(2, "alice", []byte(`{"n":2}`))
+	s.recordReplay(3, "", []byte(`{"n":3}`))
+
+	missed, current, ok := s.replaySince(0, "alice")
+	if !ok || current != 3 {
+		t.Fatalf("ok = %v, current = %d", ok, current)
+	}
+	if len(missed) != 2 || string(missed[1]) != `{"n":3}` {
+		t.Fatalf("unexpected replay: %q", missed)
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"log"
+)
+
+// Room 참여자 권한
+const (
+	roleViewer = "viewer" // 읽기 전용 (리뷰어)
+	roleEditor = "editor" // 캔버스 편집 가능
+	roleHost   = "host"   // 편집 + 참여자 권한 변경
+)
+
+// 권한 순위 (높을수록 권한이 큼)
+var roleRank = map[string]int{
+	roleViewer: 1,
+	roleEditor: 2,
+	roleHost:   3,
+}
+
+// host만 보낼 수 있는 메시지 타입
+var hostMessageTypes = map[string]bool{
+	"set-role":        true,
+	"recording-start": true,
+	"recording-stop":  true,
+	"viewport-update": true,
+}
+
+// viewer가 보낼 수 없는 메시지 타입 (Room 상태 변경)
+var mutatingMessageTypes = map[string]bool{
+	"sync-nodes":                true,
+	"patch-nodes":               true,
+	"undo":                      true,
+	"redo":                      true,
+	"lock_item":                 true,
+	"unlock_item":               true,
+	"canvas_items_update":       true,
+	"sections_update":           true,
+	"history_visibility_update": true,
+	"item_position_update":      true,
+	"section_position_update":   true,
+	"label_update":              true,
+	"canvas_state_response":     true,
+}
+
+// normalizeRole - 알 수 없는 값은 빈 문자열
+func normalizeRole(role string) string {
+	if _, ok := roleRank[role]; ok {
+		return role
+	}
+	return ""
+}
+
+// roleFromMembership - quel_organization_member.role → Room 권한
+func roleFromMembership(memberRole string) string {
+	switch memberRole {
+	case "owner", "admin", "host":
+		return roleHost
+	case "viewer", "guest", "reviewer":
+		return roleViewer
+	default:
+		return roleEditor
+	}
+}
+
+// resolveRole - 접속 시 권한 결정
+// 호스트가 지정한 권한이 있으면 우선, 없으면 멤버십 권한에서 요청 권한(낮추기만 허용)을 적용
+func resolveRole(baseRole string, requestedRole string, override string) string {
+	if override != "" {
+		return override
+	}
+	if requested := normalizeRole(requestedRole); requested != "" && roleRank[requested] < roleRank[baseRole] {
+		return requested
+	}
+	return baseRole
+}
+
+// getRole - 현재 권한
+func (c *Client) getRole() string {
+	c.roleMutex.RLock()
+	defer c.roleMutex.RUnlock()
+	return c.role
+}
+
+// setRole - 권한 변경
+func (c *Client) setRole(role string) {
+	c.roleMutex.Lock()
+	c.role = role
+	c.roleMutex.Unlock()
+}
+
+// canSend - 메시지 타입 전송 권한 확인
+func (c *Client) canSend(messageType string) bool {
+	role := c.getRole()
+	if hostMessageTypes[messageType] {
+		return role == roleHost
+	}
+	if mutatingMessageTypes[messageType] {
+		return role != roleViewer
+	}
+	return true
+}
+
+// roleOverride - 호스트가 지정한 권한 (재접속 시 유지)
+func (s *Session) roleOverride(userId string) string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.roleOverrides[userId]
+}
+
+// applyRoleChange - 권한 변경을 세션과 로컬 클라이언트에 반영
+func (s *Session) applyRoleChange(targetUserId string, role string) {
+	s.mutex.Lock()
+	if s.roleOverrides == nil {
+		s.roleOverrides = make(map[string]string)
+	}
+	s.roleOverrides[targetUserId] = role
+	client := s.clients[targetUserId]
+	s.mutex.Unlock()
+
+	if client != nil {
+		client.setRole(role)
+		log.Printf("🔑 [Roles] %s is now %s in room %s", targetUserId, role, s.id)
+	}
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	supa "github.com/supabase-community/supabase-go"
+
+	"quel-canvas-server/modules/common/config"
+)
+
+// Room 스냅샷 저장 위치
+const (
+	roomSnapshotSuffix = ":snapshot"
+	roomSnapshotTable  = "quel_canvas_room_snapshots"
+	snapshotTimeout    = 5 * time.Second
+)
+
+// Visual Editor Room 상태 스냅샷
+type roomSnapshot struct {
+	Nodes      []interface{} `json:"nodes"`
+	Edges      []interface{} `json:"edges"`
+	LastSyncBy string        `json:"lastSyncBy"`
+	LastSyncAt time.Time     `json:"lastSyncAt"`
+	Revision   int64         `json:"revision"`
+}
+
+// quel_canvas_room_snapshots 테이블 행
+type roomSnapshotRow struct {
+	RoomKey     string        `json:"room_key"`
+	OrgId       string        `json:"org_id"`
+	WorkspaceId string        `json:"workspace_id"`
+	Nodes       []interface{} `json:"nodes"`
+	Edges       []interface{} `json:"edges"`
+	LastSyncBy  string        `json:"last_sync_by"`
+	LastSyncAt  time.Time     `json:"last_sync_at"`
+	Revision    int64         `json:"revision"`
+}
+
+// SnapshotStore - Room 상태 영속화 (Redis + 선택적 Supabase)
+type SnapshotStore struct {
+	rdb      *redis.Client
+	supabase *supa.Client // nil이면 Redis에만 저장
+	ttl      time.Duration
+	debounce time.Duration
+}
+
+// nil이면 스냅샷 저장 비활성화 (메모리 상태만 유지)
+var snapshotStore *SnapshotStore
+
+// newSnapshotStore - SnapshotStore 생성
+func newSnapshotStore(rdb *redis.Client, cfg *config.Config) *SnapshotStore {
+	store := &SnapshotStore{
+		rdb:      rdb,
+		ttl:      cfg.RoomSnapshotTTL,
+		debounce: cfg.RoomSnapshotDebounce,
+	}
+
+	if cfg.RoomSnapshotSupabase {
+		supabaseClient, err := supa.NewClient(cfg.SupabaseURL, cfg.SupabaseServiceKey, nil)
+		if err != nil {
+			log.Printf("⚠️ [Snapshot] Failed to create Supabase client - Redis only: %v", err)
+		} else {
+			store.supabase = supabaseClient
+		}
+	}
+
+	log.Printf("✅ [Snapshot] Room snapshot store enabled (TTL: %v, debounce: %v, Supabase: %v)",
+		store.ttl, store.debounce, store.supabase != nil)
+	return store
+}
+
+func roomSnapshotKey(roomKey string) string {
+	return roomChannelPrefix + roomKey + roomSnapshotSuffix
+}
+
+// load - 저장된 스냅샷 조회 (Redis 우선, 없으면 Supabase)
+func (st *SnapshotStore) load(roomKey string) (*roomSnapshot, error) {
+	if st == nil {
+		return nil, nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), snapshotTimeout)
+	defer cancel()
+
+	data, err := st.rdb.Get(ctx, roomSnapshotKey(roomKey)).Bytes()
+	if err == nil {
+		var snapshot roomSnapshot
+		if err := json.Unmarshal(data, &snapshot); err != nil {
+			return nil, fmt.Errorf("failed to parse snapshot: %w", err)
+		}
+		return &snapshot, nil
+	}
+	if err != redis.Nil {
+		return nil, fmt.Errorf("failed to read snapshot from Redis: %w", err)
+	}
+
+	if st.supabase == nil {
+		return nil, nil
+	}
+
+	var rows []roomSnapshotRow
+	_, err = st.supabase.From(roomSnapshotTable).
+		Select("*", "", false).
+		Eq("room_key", roomKey).
+		ExecuteTo(&rows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot from Supabase: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	snapshot := &roomSnapshot{
+		Nodes:      rows[0].Nodes,
+		Edges:      rows[0].Edges,
+		LastSyncBy: rows[0].LastSyncBy,
+		LastSyncAt: rows[0].LastSyncAt,
+		Revision:   rows[0].Revision,
+	}
+
+	// 다음 로드를 위해 Redis 캐시 재생성
+	if data, err := json.Marshal(snapshot); err == nil {
+		st.rdb.Set(ctx, roomSnapshotKey(roomKey), data, st.ttl)
+	}
+	return snapshot, nil
+}
+
+// save - 스냅샷 저장
+func (st *SnapshotStore) save(roomKey string, snapshot *roomSnapshot) error {
+	if st == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), snapshotTimeout)
+	defer cancel()
+
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return fmt.Errorf("failed to marshal snapshot: %w", err)
+	}
+
+	if err := st.rdb.Set(ctx, roomSnapshotKey(roomKey), data, st.ttl).Err(); err != nil {
+		return fmt.Errorf("failed to write snapshot to Redis: %w", err)
+	}
+
+	if st.supabase == nil {
+		return nil
+	}
+
+	orgId, workspaceId, _ := strings.Cut(roomKey, ":")
+	row := roomSnapshotRow{
+		RoomKey:     roomKey,
+		OrgId:       orgId,
+		WorkspaceId: workspaceId,
+		Nodes:       snapshot.Nodes,
+		Edges:       snapshot.Edges,
+		LastSyncBy:  snapshot.LastSyncBy,
+		LastSyncAt:  snapshot.LastSyncAt,
+		Revision:    snapshot.Revision,
+	}
+	_, _, err = st.supabase.From(roomSnapshotTable).
+		Upsert(row, "room_key", "minimal", "").
+		Execute()
+	if err != nil {
+		return fmt.Errorf("failed to write snapshot to Supabase: %w", err)
+	}
+	return nil
+}
+
+// ensureLoaded - 세션 최초 사용 시 저장된 스냅샷 로드 (1회)
+func (s *Session) ensureLoaded() {
+	s.loadOnce.Do(func() {
+		// 다른 인스턴스에서 시작한 녹화 이어서 기록
+		s.loadRecording()
+
+		snapshot, err := snapshotStore.load(s.id)
+		if err != nil {
+			log.Printf("❌ [Snapshot] Failed to load room %s: %v", s.id, err)
+			return
+		}
+		if snapshot == nil {
+			return
+		}
+
+		s.mutex.Lock()
+		// 로드 중에 들어온 동기화가 있으면 그쪽이 최신
+		if s.lastSyncAt.IsZero() {
+			s.nodes = snapshot.Nodes
+			s.edges = snapshot.Edges
+			s.lastSyncBy = snapshot.LastSyncBy
+			s.lastSyncAt = snapshot.LastSyncAt
+			// 요소별 변경 이력은 저장하지 않으므로 복원 시점을 전체 동기화로 간주
+			s.revision = snapshot.Revision
+			s.fullSyncRevision = snapshot.Revision
+		}
+		s.mutex.Unlock()
+
+		log.Printf("📂 [Snapshot] Restored room %s (%d nodes, %d edges, last sync by %s at %v)",
+			s.id, len(snapshot.Nodes), len(snapshot.Edges), snapshot.LastSyncBy, snapshot.LastSyncAt)
+	})
+}
+
+// scheduleSnapshot - sync-nodes 이후 저장 예약 (debounce 동안의 동기화는 한 번에 저장)
+func (s *Session) scheduleSnapshot() {
+	if snapshotStore == nil {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.snapshotTimer != nil {
+		return
+	}
+	s.snapshotTimer = time.AfterFunc(snapshotStore.debounce, s.flushSnapshot)
+}
+
+// flushSnapshot - 현재 Room 상태 즉시 저장
+func (s *Session) flushSnapshot() {
+	s.mutex.Lock()
+	if s.snapshotTimer != nil {
+		s.snapshotTimer.Stop()
+		s.snapshotTimer = nil
+	}
+	snapshot := &roomSnapshot{
+		Nodes:      s.nodes,
+		Edges:      s.edges,
+		LastSyncBy: s.lastSyncBy,
+		LastSyncAt: s.lastSyncAt,
+		Revision:   s.revision,
+	}
+	s.mutex.Unlock()
+
+	if err := snapshotStore.save(s.id, snapshot); err != nil {
+		log.Printf("❌ [Snapshot] Failed to save room %s: %v", s.id, err)
+		return
+	}
+	log.Printf("💾 [Snapshot] Saved room %s (%d nodes, %d edges)", s.id, len(snapshot.Nodes), len(snapshot.Edges))
+}
+
+// hasPendingSnapshot - 아직 저장되지 않은 변경이 있는지
+func (s *Session) hasPendingSnapshot() bool {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.snapshotTimer != nil
+}
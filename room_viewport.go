@@ -0,0 +1,157 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+)
+
+// 줌 허용 범위 (React Flow minZoom/maxZoom보다 넉넉하게)
+const (
+	viewportMinZoom = 0.01
+	viewportMaxZoom = 100.0
+)
+
+// hostViewport - 호스트가 마지막으로 보낸 화면 위치 (follow 중인 클라이언트와 늦게 들어온 사용자에게 전달)
+type hostViewport struct {
+	UserId    string    `json:"userId"`
+	UserName  string    `json:"userName"`
+	X         float64   `json:"x"`
+	Y         float64   `json:"y"`
+	Zoom      float64   `json:"zoom"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// parseViewport - viewport-update의 data에서 x/y/zoom 추출
+func parseViewport(data map[string]interface{}) (x float64, y float64, zoom float64, err error) {
+	x, okX := data["x"].(float64)
+	y, okY := data["y"].(float64)
+	zoom, okZoom := data["zoom"].(float64)
+	if !okX || !okY || !okZoom {
+		return 0, 0, 0, fmt.Errorf("viewport requires x, y and zoom")
+	}
+	if zoom < viewportMinZoom || zoom > viewportMaxZoom {
+		return 0, 0, 0, fmt.Errorf("zoom out of range")
+	}
+	return x, y, zoom, nil
+}
+
+// viewportMessage - follow 중인 클라이언트에게 보내는 viewport-update
+func viewportMessage(viewport hostViewport) Message {
+	return Message{
+		Type:     "viewport-update",
+		UserId:   viewport.UserId,
+		UserName: viewport.UserName,
+		Data: map[string]interface{}{
+			"x":         viewport.X,
+			"y":         viewport.Y,
+			"zoom":      viewport.Zoom,
+			"updatedAt": viewport.UpdatedAt,
+		},
+	}
+}
+
+// currentViewport - 마지막 호스트 화면 위치 (없으면 nil)
+func (s *Session) currentViewport() *hostViewport {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	if s.viewport == nil {
+		return nil
+	}
+	viewport := *s.viewport
+	return &viewport
+}
+
+// updateViewport - 호스트 화면 위치 저장 후 follow 중인 클라이언트에게 전달 (다른 인스턴스로도 발행)
+func (s *Session) updateViewport(c *Client, message Message) error {
+	x, y, zoom, err := parseViewport(message.Data)
+	if err != nil {
+		return err
+	}
+
+	viewport := hostViewport{
+		UserId:    c.userId,
+		UserName:  c.userName,
+		X:         x,
+		Y:         y,
+		Zoom:      zoom,
+		UpdatedAt: time.Now(),
+	}
+	s.mutex.Lock()
+	s.viewport = &viewport
+	s.mutex.Unlock()
+
+	payload, err := json.Marshal(viewportMessage(viewport))
+	if err != nil {
+		return err
+	}
+	s.deliverToFollowers(c.userId, c.userId, payload)
+	roomBus.publish(s.id, c.userId, payload)
+	return nil
+}
+
+// applyRemoteViewport - 다른 인스턴스 호스트의 화면 위치 반영
+func (s *Session) applyRemoteViewport(message Message, exclude string, payload []byte) {
+	x, y, zoom, err := parseViewport(message.Data)
+	if err != nil {
+		return
+	}
+	s.mutex.Lock()
+	s.viewport = &hostViewport{
+		UserId:    message.UserId,
+		UserName:  message.UserName,
+		X:         x,
+		Y:         y,
+		Zoom:      zoom,
+		UpdatedAt: time.Now(),
+	}
+	s.mutex.Unlock()
+
+	s.deliverToFollowers(exclude, message.UserId, payload)
+}
+
+// setFollowing - follow 구독 시작/해제
+func (s *Session) setFollowing(userId string, follow bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if !follow {
+		delete(s.followers, userId)
+		return
+	}
+	if s.followers == nil {
+		s.followers = make(map[string]bool)
+	}
+	s.followers[userId] = true
+}
+
+// handleFollow - follow / unfollow 처리 (follow 시작하면 현재 호스트 화면 위치를 바로 전송)
+func (s *Session) handleFollow(c *Client, follow bool) {
+	s.setFollowing(c.userId, follow)
+	c.sendMessage(Message{
+		Type: "follow-state",
+		Data: map[string]interface{}{"following": follow},
+	})
+
+	if !follow {
+		return
+	}
+	if viewport := s.currentViewport(); viewport != nil && viewport.UserId != c.userId {
+		c.sendMessage(viewportMessage(*viewport))
+	}
+	log.Printf("👀 [Viewport] %s is following the host in room %s", c.userId, s.id)
+}
+
+// deliverToFollowers - follow 중인 로컬 클라이언트에게만 전달 (사용자별 최신 화면 위치만 유지)
+func (s *Session) deliverToFollowers(excludeUserId string, hostUserId string, payload []byte) {
+	select {
+	case s.outbound <- hubOutbound{
+		exclude:       excludeUserId,
+		coalesceKey:   "viewport-update:" + hostUserId,
+		payload:       payload,
+		followersOnly: true,
+	}:
+	case <-s.stopped:
+	}
+}
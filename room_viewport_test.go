@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// receivedTypes - 대기열의 메시지를 꺼내 타입별로 반환
+func receivedTypes(c *Client) map[string][]Message {
+	received := make(map[string][]Message)
+	payloads, _ := c.outbox.take()
+	for _, payload := range payloads {
+		var message Message
+		json.Unmarshal(payload, &message)
+		received[message.Type] = append(received[message.Type], message)
+	}
+	return received
+}
+
+func TestSessionViewportOnlyReachesFollowers(t *testing.T) {
+	s := newSession("org:present")
+	defer s.stop(true)
+
+	host := newTestClient("host")
+	host.role = roleHost
+	follower := newTestClient("follower")
+	other := newTestClient("other")
+	s.addClient(host)
+	s.addClient(follower)
+	s.addClient(other)
+
+	s.handleFollow(follower, true)
+	for i := 1; i <= 3; i++ {
+		viewport := Message{Type: "viewport-update", Data: map[string]interface{}{"x": float64(i), "y": 0.0, "zoom": 1.5}}
+		if err := s.updateViewport(host, viewport); err != nil {
+			t.Fatalf("updateViewport: %v", err)
+		}
+	}
+
+	// 대기열에서 같은 호스트의 이전 위치는 대체되므로 마지막 값만 확인
+	var updates []Message
+	waitFor(t, "viewport-update for follower", func() bool {
+		updates = append(updates, receivedTypes(follower)["viewport-update"]...)
+		return len(updates) > 0 && updates[len(updates)-1].Data["x"] == float64(3)
+	})
+	if n := len(receivedTypes(other)["viewport-update"]); n != 0 {
+		t.Fatalf("non-follower received %d viewport updates", n)
+	}
+
+	// 늦게 들어온 사용자는 initial-state에서 호스트 화면 위치를 받음
+	late := newTestClient("late")
+	s.sendInitialState(late, false)
+	states := receivedTypes(late)["initial-state"]
+	if len(states) != 1 {
+		t.Fatalf("expected initial-state, got %d", len(states))
+	}
+	viewport, _ := states[0].Data["hostViewport"].(map[string]interface{})
+	if viewport["x"] != float64(3) || viewport["zoom"] != 1.5 || viewport["userId"] != "host" {
+		t.Fatalf("unexpected host viewport in initial-state: %+v", states[0].Data["hostViewport"])
+	}
+}
+
+func TestParseViewportRejectsInvalidZoom(t *testing.T) {
+	if _, _, _, err := parseViewport(map[string]interface{}{"x": 0.0, "y": 0.0}); err == nil {
+		t.Fatalf("missing zoom accepted")
+	}
+	if _, _, _, err := parseViewport(map[string]interface{}{"x": 0.0, "y": 0.0, "zoom": 0.0}); err == nil {
+		t.Fatalf("zero zoom accepted")
+	}
+}
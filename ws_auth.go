@@ -0,0 +1,264 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	supa "github.com/supabase-community/supabase-go"
+
+	"quel-canvas-server/modules/common/config"
+)
+
+// Sec-WebSocket-Protocol로 토큰을 보낼 때 사용하는 프로토콜 이름
+// 클라이언트: new WebSocket(url, ["bearer", accessToken])
+const bearerSubprotocol = "bearer"
+
+// JWT 만료 시간 허용 오차
+const jwtLeeway = 30 * time.Second
+
+// Supabase Auth access token 클레임
+type supabaseClaims struct {
+	Sub          string                 `json:"sub"`
+	Email        string                 `json:"email"`
+	Role         string                 `json:"role"`
+	Exp          int64                  `json:"exp"`
+	UserMetadata map[string]interface{} `json:"user_metadata"`
+}
+
+// displayName - 클레임에서 표시 이름 추출
+func (c *supabaseClaims) displayName() string {
+	for _, key := range []string{"full_name", "name", "user_name"} {
+		if name, ok := c.UserMetadata[key].(string); ok && name != "" {
+			return name
+		}
+	}
+	return c.Email
+}
+
+// 인증된 WebSocket 사용자
+type wsIdentity struct {
+	UserId     string
+	UserName   string
+	Email      string
+	MemberRole string // quel_organization_member.role (없으면 빈 문자열)
+}
+
+// WSAuthenticator - WebSocket 연결 인증 (JWT 검증, 워크스페이스 멤버십)
+type WSAuthenticator struct {
+	jwtSecret []byte
+	supabase  *supa.Client
+}
+
+// nil이면 인증 비활성화 (WS_AUTH_DISABLED=true일 때만, query 파라미터 신뢰 - 개발용)
+var wsAuth *WSAuthenticator
+
+// newWSAuthenticator - 설정 기반 인증기 생성
+// WS_AUTH_DISABLED=true가 아니면 SUPABASE_JWT_SECRET과 Supabase 클라이언트가 필수 (실패 시 서버 시작 중단)
+func newWSAuthenticator(cfg *config.Config) (*WSAuthenticator, error) {
+	if cfg.WSAuthDisabled {
+		log.Println("⚠️ [WSAuth] WS_AUTH_DISABLED=true - WebSocket authentication disabled (development only)")
+		return nil, nil
+	}
+	if cfg.SupabaseJWTSecret == "" {
+		return nil, fmt.Errorf("SUPABASE_JWT_SECRET is required for WebSocket authentication (set WS_AUTH_DISABLED=true for local development)")
+	}
+
+	supabaseClient, err := supa.NewClient(cfg.SupabaseURL, cfg.SupabaseServiceKey, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Supabase client for WebSocket authentication: %w", err)
+	}
+
+	log.Println("✅ [WSAuth] WebSocket authentication enabled")
+	return &WSAuthenticator{
+		jwtSecret: []byte(cfg.SupabaseJWTSecret),
+		supabase:  supabaseClient,
+	}, nil
+}
+
+// checkOrigin - upgrader.CheckOrigin (허용 목록이 없으면 거부, WS_AUTH_DISABLED=true일 때만 모두 허용)
+func checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		// 브라우저가 아닌 클라이언트
+		return true
+	}
+
+	cfg := config.GetConfig()
+	allowed := cfg.WSAllowedOrigins
+	if len(allowed) == 0 {
+		// 허용 목록 없이 모두 받으면 다른 사이트가 사용자 쿠키/토큰으로 WebSocket을 열 수 있음
+		if cfg.WSAuthDisabled {
+			return true
+		}
+		log.Printf("🚫 [WSAuth] Origin %s rejected - WS_ALLOWED_ORIGINS not set", origin)
+		return false
+	}
+
+	originURL, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	for _, pattern := range allowed {
+		if pattern == "*" || pattern == origin {
+			return true
+		}
+		// "https://*.example.com" 형태의 서브도메인 와일드카드
+		if scheme, host, ok := strings.Cut(pattern, "://*."); ok {
+			if originURL.Scheme == scheme && strings.HasSuffix(originURL.Host, "."+host) {
+				return true
+			}
+		}
+	}
+
+	log.Printf("🚫 [WSAuth] Origin not allowed: %s", origin)
+	return false
+}
+
+// extractBearerToken - query(token/access_token), Sec-WebSocket-Protocol, Authorization 순으로 토큰 추출
+func extractBearerToken(r *http.Request) string {
+	query := r.URL.Query()
+	if token := query.Get("token"); token != "" {
+		return token
+	}
+	if token := query.Get("access_token"); token != "" {
+		return token
+	}
+
+	// ["bearer", "<token>"]
+	protocols := websocketSubprotocols(r)
+	for i, protocol := range protocols {
+		if protocol == bearerSubprotocol && i+1 < len(protocols) {
+			return protocols[i+1]
+		}
+	}
+
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		return strings.TrimPrefix(auth, "Bearer ")
+	}
+	return ""
+}
+
+func websocketSubprotocols(r *http.Request) []string {
+	var protocols []string
+	for _, header := range r.Header.Values("Sec-Websocket-Protocol") {
+		for _, protocol := range strings.Split(header, ",") {
+			if protocol = strings.TrimSpace(protocol); protocol != "" {
+				protocols = append(protocols, protocol)
+			}
+		}
+	}
+	return protocols
+}
+
+// verifyToken - Supabase JWT(HS256) 로컬 검증
+func (a *WSAuthenticator) verifyToken(token string) (*supabaseClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("malformed token")
+	}
+
+	headerBytes, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("invalid token header: %w", err)
+	}
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := json.Unmarshal(headerBytes, &header); err != nil {
+		return nil, fmt.Errorf("invalid token header: %w", err)
+	}
+	if header.Alg != "HS256" {
+		return nil, fmt.Errorf("unsupported token algorithm: %s", header.Alg)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("invalid token signature: %w", err)
+	}
+	mac := hmac.New(sha256.New, a.jwtSecret)
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return nil, fmt.Errorf("token signature mismatch")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("invalid token payload: %w", err)
+	}
+	var claims supabaseClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, fmt.Errorf("invalid token payload: %w", err)
+	}
+
+	if claims.Sub == "" {
+		return nil, fmt.Errorf("token has no subject")
+	}
+	if claims.Exp == 0 || time.Now().Add(-jwtLeeway).Unix() > claims.Exp {
+		return nil, fmt.Errorf("token expired")
+	}
+	if claims.Role != "" && claims.Role != "authenticated" {
+		return nil, fmt.Errorf("token role not allowed: %s", claims.Role)
+	}
+	return &claims, nil
+}
+
+// workspaceMembership - 조직 멤버십 확인 (워크스페이스는 조직 하위이므로 조직 active 멤버면 접근 가능)
+// 멤버이면 멤버 role 값을 함께 반환
+func (a *WSAuthenticator) workspaceMembership(orgId string, workspaceId string, userId string) (bool, string, error) {
+	var members []struct {
+		OrgId string `json:"org_id"`
+		Role  string `json:"role"`
+	}
+
+	_, err := a.supabase.From("quel_organization_member").
+		Select("*", "", false).
+		Eq("org_id", orgId).
+		Eq("member_id", userId).
+		Eq("status", "active").
+		ExecuteTo(&members)
+	if err != nil {
+		return false, "", fmt.Errorf("failed to check membership: %w", err)
+	}
+
+	if len(members) == 0 {
+		log.Printf("🚫 [WSAuth] User %s is not a member of org %s (workspace %s)", userId, orgId, workspaceId)
+		return false, "", nil
+	}
+	return true, members[0].Role, nil
+}
+
+// authenticate - 업그레이드 전 요청 인증, 실패 시 HTTP 상태 코드와 함께 에러 반환
+func (a *WSAuthenticator) authenticate(r *http.Request, orgId string, workspaceId string) (*wsIdentity, int, error) {
+	token := extractBearerToken(r)
+	if token == "" {
+		return nil, http.StatusUnauthorized, fmt.Errorf("missing bearer token")
+	}
+
+	claims, err := a.verifyToken(token)
+	if err != nil {
+		return nil, http.StatusUnauthorized, err
+	}
+
+	isMember, memberRole, err := a.workspaceMembership(orgId, workspaceId, claims.Sub)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	if !isMember {
+		return nil, http.StatusForbidden, fmt.Errorf("not a member of this workspace")
+	}
+
+	return &wsIdentity{
+		UserId:     claims.Sub,
+		UserName:   claims.displayName(),
+		Email:      claims.Email,
+		MemberRole: memberRole,
+	}, http.StatusOK, nil
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"sync"
+)
+
+// 전송 대기 중인 lossless 메시지가 이 개수를 넘으면 대기열을 비우고 resync-required 전송
+const outboxResyncThreshold = 1024
+
+// presence-batch 자리 표시 frame key (전송 시점에 합쳐진 업데이트로 직렬화)
+const presenceBatchKey = "presence-batch"
+
+// outboxFrame - 전송 대기 메시지 (key가 있으면 같은 key의 최신 메시지만 유지)
+type outboxFrame struct {
+	key     string
+	payload []byte
+}
+
+// clientOutbox - 클라이언트별 전송 대기열
+// 커서/선택처럼 최신 값만 의미 있는 메시지는 사용자별로 합치고, 노드 동기화 등은 모두 보존
+type clientOutbox struct {
+	mutex    sync.Mutex
+	frames   []outboxFrame
+	keyIndex map[string]int // coalesce key → frames 위치
+	lossless int            // 대기 중인 lossless 메시지 수
+	closed   bool
+	ready    chan struct{} // 새 메시지 또는 close 알림 (writePump 깨우기)
+
+	// 아직 보내지 못한 presence-batch 업데이트 (type:userId → 최신 메시지)
+	presence      map[string]Message
+	presenceOrder []string
+}
+
+func newClientOutbox() *clientOutbox {
+	return &clientOutbox{
+		keyIndex: make(map[string]int),
+		ready:    make(chan struct{}, 1),
+	}
+}
+
+// coalesceKey - 합칠 수 있는 메시지면 "type:userId", 아니면 빈 문자열
+func coalesceKey(message *Message) string {
+	if !ephemeralMessageTypes[message.Type] {
+		return ""
+	}
+	return message.Type + ":" + message.UserId
+}
+
+// resyncRequiredPayload - 대기열 초과 시 보내는 프레임
+var resyncRequiredPayload, _ = json.Marshal(Message{
+	Type:   "resync-required",
+	Reason: "send buffer overflow",
+})
+
+// push - 메시지 추가 (닫혔으면 false)
+// 반환값 overflow는 이번 추가로 대기열이 resync-required로 대체됐는지 여부
+func (o *clientOutbox) push(payload []byte, key string) (ok bool, overflow bool) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	if o.closed {
+		return false, false
+	}
+
+	if key != "" {
+		if i, exists := o.keyIndex[key]; exists {
+			o.frames[i].payload = payload
+			return true, false
+		}
+		o.keyIndex[key] = len(o.frames)
+	} else {
+		o.lossless++
+	}
+	o.frames = append(o.frames, outboxFrame{key: key, payload: payload})
+
+	if o.lossless > outboxResyncThreshold {
+		// 따라잡을 수 없으므로 쌓인 메시지를 버리고 전체 상태 재요청 유도
+		o.frames = []outboxFrame{{payload: resyncRequiredPayload}}
+		o.keyIndex = make(map[string]int)
+		o.lossless = 1
+		overflow = true
+
+		// 커서/선택은 resync와 무관하게 최신 값 유지
+		if len(o.presenceOrder) > 0 {
+			o.keyIndex[presenceBatchKey] = len(o.frames)
+			o.frames = append(o.frames, outboxFrame{key: presenceBatchKey})
+		}
+	}
+
+	o.signal()
+	return true, overflow
+}
+
+// pushPresence - presence-batch에 업데이트 합치기 (아직 보내지 않은 batch가 있으면 그 안에서 사용자별 최신 값으로 대체)
+func (o *clientOutbox) pushPresence(updates []Message) bool {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	if o.closed {
+		return false
+	}
+
+	if o.presence == nil {
+		o.presence = make(map[string]Message)
+	}
+	for _, update := range updates {
+		key := coalesceKey(&update)
+		if _, exists := o.presence[key]; !exists {
+			o.presenceOrder = append(o.presenceOrder, key)
+		}
+		o.presence[key] = update
+	}
+
+	if _, exists := o.keyIndex[presenceBatchKey]; !exists {
+		o.keyIndex[presenceBatchKey] = len(o.frames)
+		o.frames = append(o.frames, outboxFrame{key: presenceBatchKey})
+	}
+	o.signal()
+	return true
+}
+
+// take - 대기 중인 메시지를 모두 꺼냄 (closed면 더 이상 보낼 것 없음)
+func (o *clientOutbox) take() ([][]byte, bool) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	payloads := make([][]byte, 0, len(o.frames))
+	for _, frame := range o.frames {
+		if frame.key == presenceBatchKey {
+			if payload := o.presencePayload(); payload != nil {
+				payloads = append(payloads, payload)
+			}
+			continue
+		}
+		payloads = append(payloads, frame.payload)
+	}
+	o.frames = o.frames[:0]
+	o.keyIndex = make(map[string]int)
+	o.lossless = 0
+	o.presence = nil
+	o.presenceOrder = nil
+	return payloads, o.closed
+}
+
+// presencePayload - 모인 업데이트를 presence-batch 프레임으로 직렬화
+func (o *clientOutbox) presencePayload() []byte {
+	updates := make([]Message, 0, len(o.presenceOrder))
+	for _, key := range o.presenceOrder {
+		updates = append(updates, o.presence[key])
+	}
+	payload, err := json.Marshal(Message{Type: "presence-batch", Updates: updates})
+	if err != nil {
+		log.Printf("Error marshaling presence batch: %v", err)
+		return nil
+	}
+	return payload
+}
+
+// close - 대기열 종료 (이미 닫혔으면 false)
+func (o *clientOutbox) close() bool {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	if o.closed {
+		return false
+	}
+	o.closed = true
+	o.signal()
+	return true
+}
+
+func (o *clientOutbox) signal() {
+	select {
+	case o.ready <- struct{}{}:
+	default:
+	}
+}
+
+// enqueue - 클라이언트 대기열에 추가 (coalesceKey가 있으면 같은 key의 이전 메시지 대체)
+func (c *Client) enqueue(payload []byte, key string) bool {
+	ok, overflow := c.outbox.push(payload, key)
+	if overflow {
+		log.Printf("⚠️ [Outbox] %s fell %d messages behind - sent resync-required", c.userId, outboxResyncThreshold)
+	}
+	return ok
+}
+
+// trySend - lossless 메시지 추가 (닫혔으면 false)
+func (c *Client) trySend(payload []byte) bool {
+	return c.enqueue(payload, "")
+}
+
+// closeSend - 대기열 종료, writePump가 남은 메시지를 보낸 뒤 연결을 닫음 (이미 닫혔으면 false)
+func (c *Client) closeSend() bool {
+	return c.outbox.close()
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func mustMarshal(t *testing.T, message Message) []byte {
+	t.Helper()
+	payload, err := json.Marshal(message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return payload
+}
+
+func TestOutboxCoalescesCursorPerUser(t *testing.T) {
+	o := newClientOutbox()
+
+	sync1 := mustMarshal(t, Message{Type: "nodes-updated", Revision: 1})
+	o.push(sync1, "")
+	for i := 0; i < 100; i++ {
+		cursor := Message{Type: "cursor-update", UserId: "alice", CursorX: float64(i)}
+		o.push(mustMarshal(t, cursor), coalesceKey(&cursor))
+	}
+	bob := Message{Type: "cursor-update", UserId: "bob", CursorX: 7}
+	o.push(mustMarshal(t, bob), coalesceKey(&bob))
+	sync2 := mustMarshal(t, Message{Type: "nodes-updated", Revision: 2})
+	o.push(sync2, "")
+
+	payloads, closed := o.take()
+	if closed {
+		t.Fatalf("outbox unexpectedly closed")
+	}
+	if len(payloads) != 4 {
+		t.Fatalf("expected 4 frames (2 syncs, 1 cursor per user), got %d", len(payloads))
+	}
+	if string(payloads[0]) != string(sync1) || string(payloads[3]) != string(sync2) {
+		t.Fatalf("lossless frames were reordered or dropped")
+	}
+
+	var alice Message
+	json.Unmarshal(payloads[1], &alice)
+	if alice.UserId != "alice" || alice.CursorX != 99 {
+		t.Fatalf("expected latest alice cursor (99), got %s x=%v", alice.UserId, alice.CursorX)
+	}
+}
+
+func TestOutboxOverflowReplacesQueueWithResyncRequired(t *testing.T) {
+	o := newClientOutbox()
+
+	overflowed := false
+	for i := 0; i <= outboxResyncThreshold; i++ {
+		_, overflow := o.push(mustMarshal(t, Message{Type: "nodes-patched", Revision: int64(i)}), "")
+		overflowed = overflowed || overflow
+	}
+	if !overflowed {
+		t.Fatalf("expected overflow after %d lossless frames", outboxResyncThreshold+1)
+	}
+
+	after := mustMarshal(t, Message{Type: "nodes-patched", Revision: 9999})
+	o.push(after, "")
+
+	payloads, _ := o.take()
+	if len(payloads) != 2 || string(payloads[0]) != string(resyncRequiredPayload) || string(payloads[1]) != string(after) {
+		t.Fatalf("expected resync-required followed by newer frame, got %d frames", len(payloads))
+	}
+}
+
+func TestOutboxClosedRejectsPush(t *testing.T) {
+	o := newClientOutbox()
+	if !o.close() {
+		t.Fatalf("first close returned false")
+	}
+	if o.close() {
+		t.Fatalf("second close returned true")
+	}
+	if ok, _ := o.push([]byte("{}"), ""); ok {
+		t.Fatalf("push succeeded on closed outbox")
+	}
+}
+
+func TestOutboxMergesPresenceBatches(t *testing.T) {
+	o := newClientOutbox()
+
+	o.push(mustMarshal(t, Message{Type: "nodes-updated"}), "")
+	o.pushPresence([]Message{
+		{Type: "cursor-update", UserId: "alice", CursorX: 1},
+		{Type: "selection-update", UserId: "alice", ItemIds: []string{"n1"}},
+	})
+	// 아직 보내지 않은 batch에 합쳐짐
+	o.pushPresence([]Message{
+		{Type: "cursor-update", UserId: "alice", CursorX: 2},
+		{Type: "cursor-update", UserId: "bob", CursorX: 3},
+	})
+
+	payloads, _ := o.take()
+	if len(payloads) != 2 {
+		t.Fatalf("expected sync frame and one presence-batch, got %d frames", len(payloads))
+	}
+
+	var batch Message
+	if err := json.Unmarshal(payloads[1], &batch); err != nil {
+		t.Fatal(err)
+	}
+	if batch.Type != "presence-batch" || len(batch.Updates) != 3 {
+		t.Fatalf("expected presence-batch with 3 updates, got %s with %d", batch.Type, len(batch.Updates))
+	}
+	if batch.Updates[0].UserId != "alice" || batch.Updates[0].CursorX != 2 {
+		t.Fatalf("expected latest alice cursor first, got %+v", batch.Updates[0])
+	}
+}